@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	awsArn "github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
+// bucketRegions caches the region of each S3 bucket. Bucket ARNs don't include a region, but the Tagging API only
+// finds resources in the region it is called in.
+var bucketRegions = struct {
+	sync.Mutex
+	regions map[string]string
+}{
+	regions: map[string]string{},
+}
+
+// resourceRegion returns the region of the resource, looking up the region of S3 buckets.
+func resourceRegion(ctx context.Context, arn awsArn.ARN) (string, error) {
+	if arn.Service != "s3" || arn.Region != "" {
+		return arn.Region, nil
+	}
+
+	bucket, _, _ := strings.Cut(arn.Resource, "/")
+
+	return bucketRegion(ctx, bucket)
+}
+
+// bucketRegion returns the region of the bucket from the x-amz-bucket-region header of a HeadBucket call. The header is
+// returned even when the call is denied, so the call is made anonymously and works for buckets in any account.
+func bucketRegion(ctx context.Context, bucket string) (string, error) {
+	bucketRegions.Lock()
+	region, ok := bucketRegions.regions[bucket]
+	bucketRegions.Unlock()
+	if ok {
+		return region, nil
+	}
+
+	client, err := getS3Client()
+	if err != nil {
+		return "", err
+	}
+
+	region, err = manager.GetBucketRegion(ctx, client, bucket)
+	if err != nil {
+		var notFound manager.BucketNotFound
+		if errors.As(err, &notFound) {
+			// A deleted bucket has no tags, so every region reports it as missing.
+			return "us-east-1", nil
+		}
+
+		return "", fmt.Errorf("failed to find the region of bucket %q: %w", bucket, err)
+	}
+
+	bucketRegions.Lock()
+	bucketRegions.regions[bucket] = region
+	bucketRegions.Unlock()
+
+	return region, nil
+}
+
+// resetBucketRegions drops the cached regions, e.g. when the S3 endpoint changes.
+func resetBucketRegions() {
+	bucketRegions.Lock()
+	defer bucketRegions.Unlock()
+
+	bucketRegions.regions = map[string]string{}
+}
@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// coalesceWindow is how long a write waits for writes to other resources to join its TagResources or UntagResources
+// call. The engine creates resources in parallel, so sharing calls is much faster than each write waiting on the
+// limiter separately.
+const coalesceWindow = 50 * time.Millisecond
+
+// writeKey identifies the writes that can share a call: the same client, so the same region and credentials, and the
+// same tags to add or the same keys to remove.
+type writeKey struct {
+	client *taggingClient
+	untag  bool
+	tags   string
+}
+
+// pendingWrite is a call that is still collecting resources.
+type pendingWrite struct {
+	tags    map[string]string
+	tagKeys []string
+	arns    []string
+	// The callers waiting on the result for each ARN, and their contexts.
+	results  map[string][]chan error
+	contexts []context.Context
+}
+
+// writeCoalescer merges concurrent writes to different resources into shared calls.
+var writeCoalescer = struct {
+	sync.Mutex
+	pending map[writeKey]*pendingWrite
+}{
+	pending: map[writeKey]*pendingWrite{},
+}
+
+// coalesceTags adds the tags to the resource, sharing TagResources calls with concurrent writes of the same tags.
+func coalesceTags(ctx context.Context, arn string, roleArn string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	client, err := getResourceTaggingClient(ctx, arn, roleArn)
+	if err != nil {
+		return err
+	}
+
+	results := []chan error{}
+	for _, keys := range chunkTagKeys(sortedKeys(tags)) {
+		chunk := subsetTags(tags, keys)
+
+		encoded := make([]string, 0, len(keys))
+		for _, key := range keys {
+			encoded = append(encoded, key+"\x00"+chunk[key])
+		}
+
+		results = append(results, enqueueWrite(ctx, writeKey{client: client, tags: strings.Join(encoded, "\x00")}, arn, chunk, keys))
+	}
+
+	return waitForWrites(ctx, results)
+}
+
+// coalesceUntags removes the tag keys from the resource, sharing UntagResources calls with concurrent writes that
+// remove the same keys.
+func coalesceUntags(ctx context.Context, arn string, roleArn string, tagKeys []string) error {
+	if len(tagKeys) == 0 {
+		return nil
+	}
+
+	client, err := getResourceTaggingClient(ctx, arn, roleArn)
+	if err != nil {
+		return err
+	}
+
+	sorted := append([]string{}, tagKeys...)
+	slices.Sort(sorted)
+
+	results := []chan error{}
+	for _, keys := range chunkTagKeys(sorted) {
+		results = append(results, enqueueWrite(ctx, writeKey{client: client, untag: true, tags: strings.Join(keys, "\x00")}, arn, nil, keys))
+	}
+
+	return waitForWrites(ctx, results)
+}
+
+// enqueueWrite adds the resource to the pending call for the key, returning a channel that receives its result.
+// The call is sent once it has the maximum number of resources, or when the coalesce window of its first write ends.
+func enqueueWrite(ctx context.Context, key writeKey, arn string, tags map[string]string, tagKeys []string) chan error {
+	result := make(chan error, 1)
+
+	writeCoalescer.Lock()
+	defer writeCoalescer.Unlock()
+
+	write, ok := writeCoalescer.pending[key]
+	if !ok {
+		write = &pendingWrite{tags: tags, tagKeys: tagKeys, results: map[string][]chan error{}}
+		writeCoalescer.pending[key] = write
+
+		time.AfterFunc(coalesceWindow, func() {
+			writeCoalescer.Lock()
+			defer writeCoalescer.Unlock()
+
+			// The call has already been sent if it filled up.
+			if writeCoalescer.pending[key] == write {
+				delete(writeCoalescer.pending, key)
+				go sendWrite(key, write)
+			}
+		})
+	}
+
+	if _, ok := write.results[arn]; !ok {
+		write.arns = append(write.arns, arn)
+	}
+	write.results[arn] = append(write.results[arn], result)
+	write.contexts = append(write.contexts, ctx)
+
+	if len(write.arns) == maxWriteResources {
+		delete(writeCoalescer.pending, key)
+		go sendWrite(key, write)
+	}
+
+	return result
+}
+
+// sendWrite makes the call for the pending write and reports the result for each resource to its callers.
+func sendWrite(key writeKey, write *pendingWrite) {
+	ctx, cancel := writeContext(write.contexts)
+	defer cancel()
+
+	failed, err := writeBatch(ctx, key, write, write.arns)
+
+	// A single invalid resource fails the whole call, so the resources are written separately to keep the error with
+	// the resource that caused it. Retryable errors have already been retried and would fail the separate calls too.
+	if err != nil && len(write.arns) > 1 && !isRetryableError(err) && ctx.Err() == nil {
+		failed, err = map[string]error{}, nil
+		for _, arn := range write.arns {
+			arnFailed, arnErr := writeBatch(ctx, key, write, []string{arn})
+			if arnErr == nil {
+				arnErr = arnFailed[arn]
+			}
+
+			if arnErr != nil {
+				failed[arn] = arnErr
+			}
+		}
+	}
+
+	for arn, results := range write.results {
+		arnErr := err
+		if arnErr == nil {
+			arnErr = failed[arn]
+		}
+
+		for _, result := range results {
+			result <- arnErr
+		}
+	}
+}
+
+// writeBatch makes the call for the pending write with the ARNs.
+func writeBatch(ctx context.Context, key writeKey, write *pendingWrite, arns []string) (map[string]error, error) {
+	batch := resourceBatch{client: key.client, arns: arns}
+	if key.untag {
+		return untagBatch(ctx, batch, write.tagKeys)
+	}
+
+	return tagBatch(ctx, batch, write.tags)
+}
+
+// writeContext returns the context for a shared call, which is only canceled once the context of every caller is
+// done. A canceled caller stops waiting for the result, but doesn't fail the writes of the others.
+func writeContext(contexts []context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		for _, callerCtx := range contexts {
+			select {
+			case <-callerCtx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+
+	return ctx, cancel
+}
+
+func waitForWrites(ctx context.Context, results []chan error) error {
+	errs := make([]error, 0, len(results))
+	for _, result := range results {
+		select {
+		case err := <-result:
+			errs = append(errs, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return errors.Join(errs...)
+}
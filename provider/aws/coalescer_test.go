@@ -0,0 +1,187 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// coalesceAll adds the tags to every resource concurrently, returning the error of each.
+func coalesceAll(ctx context.Context, arns []string, tags map[string]string) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	errs := map[string]error{}
+	for _, arn := range arns {
+		wg.Add(1)
+		go func(arn string) {
+			defer wg.Done()
+
+			err := coalesceTags(ctx, arn, "", tags)
+
+			mu.Lock()
+			errs[arn] = err
+			mu.Unlock()
+		}(arn)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+func testARNs(prefix string, n int) []string {
+	arns := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		arns = append(arns, fmt.Sprintf("arn:aws:sqs:us-east-1:%s:%s-%02d", testAccountID, prefix, i))
+	}
+
+	return arns
+}
+
+func TestCoalesceTagsSharesCalls(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("shared", 5)
+	for arn, err := range coalesceAll(context.Background(), arns, map[string]string{"env": "prod"}) {
+		assert.NoError(t, err, arn)
+		assert.Equal(t, map[string]string{"env": "prod"}, fake.getTags(arn))
+	}
+
+	writes := fake.getWrites()
+	require.Len(t, writes, 1)
+	assert.ElementsMatch(t, arns, writes[0].arns)
+}
+
+func TestCoalesceTagsSplitsFullCalls(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("full", maxWriteResources+1)
+	for arn, err := range coalesceAll(context.Background(), arns, map[string]string{"env": "prod"}) {
+		assert.NoError(t, err, arn)
+	}
+
+	writes := fake.getWrites()
+	require.Len(t, writes, 2)
+	assert.ElementsMatch(t, arns, append(slices.Clone(writes[0].arns), writes[1].arns...))
+}
+
+func TestCoalesceTagsKeepsDifferentTagsApart(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("different", 2)
+
+	var wg sync.WaitGroup
+	for i, arn := range arns {
+		wg.Add(1)
+		go func(arn string, value string) {
+			defer wg.Done()
+			assert.NoError(t, coalesceTags(context.Background(), arn, "", map[string]string{"env": value}))
+		}(arn, fmt.Sprint(i))
+	}
+	wg.Wait()
+
+	assert.Len(t, fake.getWrites(), 2)
+}
+
+func TestCoalesceTagsReportsFailuresToTheirCaller(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("failures", 3)
+	fake.failures[arns[1]] = types.FailureInfo{
+		ErrorCode:    types.ErrorCodeInvalidParameterException,
+		ErrorMessage: aws.String("the resource doesn't support tags"),
+		StatusCode:   400,
+	}
+
+	errs := coalesceAll(context.Background(), arns, map[string]string{"env": "prod"})
+	assert.NoError(t, errs[arns[0]])
+	assert.ErrorContains(t, errs[arns[1]], "the resource doesn't support tags")
+	assert.NoError(t, errs[arns[2]])
+	assert.Len(t, fake.getWrites(), 1)
+}
+
+func TestCoalesceTagsSplitsFailedCalls(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("split", 3)
+	fake.failWrite = func(writeARNs []string) error {
+		if slices.Contains(writeARNs, arns[1]) {
+			return &smithy.GenericAPIError{Code: "InvalidParameterException", Message: "invalid ARN"}
+		}
+		return nil
+	}
+
+	errs := coalesceAll(context.Background(), arns, map[string]string{"env": "prod"})
+	assert.NoError(t, errs[arns[0]])
+	assert.ErrorContains(t, errs[arns[1]], "invalid ARN")
+	assert.NoError(t, errs[arns[2]])
+
+	// The shared call, then one call for each resource.
+	assert.Len(t, fake.getWrites(), 1+len(arns))
+}
+
+func TestCoalesceTagsCanceledCaller(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("canceled", 2)
+	canceledCtx, cancel := context.WithCancel(context.Background())
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- coalesceTags(canceledCtx, arns[0], "", map[string]string{"env": "prod"})
+	}()
+
+	// Both writes join the same call before the first caller gives up.
+	assert.NoError(t, coalesceTagsAfter(coalesceWindow/5, cancel, arns[1]))
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	writes := fake.getWrites()
+	require.Len(t, writes, 1)
+	assert.ElementsMatch(t, arns, writes[0].arns)
+}
+
+// coalesceTagsAfter waits for the delay, then cancels the other caller and adds the tags to the resource.
+func coalesceTagsAfter(delay time.Duration, cancel context.CancelFunc, arn string) error {
+	time.Sleep(delay)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- coalesceTags(context.Background(), arn, "", map[string]string{"env": "prod"})
+	}()
+
+	time.Sleep(delay)
+	cancel()
+
+	return <-errs
+}
+
+func TestWriteContext(t *testing.T) {
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+
+	ctx, cancel := writeContext([]context.Context{first, second})
+	defer cancel()
+
+	cancelFirst()
+	select {
+	case <-ctx.Done():
+		t.Fatal("the write context was canceled while a caller was still waiting")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancelSecond()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the write context wasn't canceled once every caller was done")
+	}
+}
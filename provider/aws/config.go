@@ -0,0 +1,337 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsArn "github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// Config is the provider configuration, it controls how every tagging client is authenticated.
+type Config struct {
+	Region            string            `pulumi:"region,optional"`
+	Profile           string            `pulumi:"profile,optional"`
+	SharedConfigFiles []string          `pulumi:"sharedConfigFiles,optional"`
+	AccessKey         string            `pulumi:"accessKey,optional" provider:"secret"`
+	SecretKey         string            `pulumi:"secretKey,optional" provider:"secret"`
+	Token             string            `pulumi:"token,optional" provider:"secret"`
+	AssumeRole        *AssumeRole       `pulumi:"assumeRole,optional"`
+	AccountRoles      map[string]string `pulumi:"accountRoles,optional"`
+
+	Endpoints                 *Endpoints `pulumi:"endpoints,optional"`
+	S3ForcePathStyle          bool       `pulumi:"s3ForcePathStyle,optional"`
+	SkipCredentialsValidation bool       `pulumi:"skipCredentialsValidation,optional"`
+	SkipRequestingAccountId   bool       `pulumi:"skipRequestingAccountId,optional"`
+
+	RetryMaxAttempts   int                  `pulumi:"retryMaxAttempts,optional"`
+	RetryMaxDelay      string               `pulumi:"retryMaxDelay,optional"`
+	RateLimits         map[string]RateLimit `pulumi:"rateLimits,optional"`
+	SharedRateLimitDir string               `pulumi:"sharedRateLimitDir,optional"`
+}
+
+func (c *Config) Annotate(a infer.Annotator) {
+	a.Describe(&c.Region, "The region used for resources whose ARN doesn't include one. Defaults to the region of the shared config or environment.")
+	a.Describe(&c.Profile, "The profile to use from the shared config and credentials files.")
+	a.Describe(&c.SharedConfigFiles, "Paths to the shared config and credentials files to load, instead of the default locations.")
+	a.Describe(&c.AccessKey, "The access key for API operations. Must be set together with secretKey.")
+	a.Describe(&c.SecretKey, "The secret key for API operations. Must be set together with accessKey.")
+	a.Describe(&c.Token, "The session token for temporary credentials, used together with accessKey and secretKey.")
+	a.Describe(&c.AssumeRole, "A role to assume before making any tagging calls. Resources can assume a further role with assumeRoleArn.")
+	a.Describe(&c.AccountRoles, "A map of AWS account IDs to the role to assume when tagging resources in that account. "+
+		"The * key matches every account other than the provider's own. Resources with an assumeRoleArn ignore this map.")
+	a.Describe(&c.Endpoints, "Custom endpoints for the AWS APIs, e.g. for LocalStack or VPC interface endpoints.")
+	a.Describe(&c.S3ForcePathStyle, "Use path-style addressing (http://s3.amazonaws.com/BUCKET) for the S3 requests that look up "+
+		"the region of buckets, e.g. for LocalStack.")
+	a.Describe(&c.SkipCredentialsValidation, "Skip checking that credentials can be retrieved when the provider is configured.")
+	a.Describe(&c.SkipRequestingAccountId, "Skip requesting the account ID of the provider's credentials from STS. "+
+		"When skipped, the * key of accountRoles also matches the provider's own account.")
+	a.Describe(&c.RetryMaxAttempts, "The maximum number of attempts for each tagging call that fails with a throttling or transient error. Defaults to 5.")
+	a.Describe(&c.RetryMaxDelay, "The maximum delay between attempts, e.g. 30s or 1m. Defaults to 20s.")
+	a.Describe(&c.RateLimits, "Client-side rate limits for each Tagging API operation, e.g. TagResources or GetResources. "+
+		"The limits apply to each account and region separately, and default to the Tagging API quotas.")
+	a.Describe(&c.SharedRateLimitDir, "A directory the rate limits are shared through by every provider process on the machine "+
+		"that uses it, e.g. when deploying several stacks in parallel. Supported on Linux and macOS.")
+}
+
+// Configure validates the configuration and sets up the AWS config shared by the tagging clients.
+func (c *Config) Configure(ctx p.Context) error {
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return fmt.Errorf("accessKey and secretKey must be set together")
+	}
+
+	if c.Token != "" && c.AccessKey == "" {
+		return fmt.Errorf("token can only be used together with accessKey and secretKey")
+	}
+
+	for _, file := range c.SharedConfigFiles {
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("invalid shared config file %q: %w", file, err)
+		}
+	}
+
+	if c.AssumeRole != nil {
+		if err := c.AssumeRole.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Endpoints != nil {
+		if err := c.Endpoints.validate(); err != nil {
+			return err
+		}
+	}
+
+	for accountID, roleArn := range c.AccountRoles {
+		if accountID != "*" && !accountIDPattern.MatchString(accountID) {
+			return fmt.Errorf("invalid accountRoles key %q: must be a 12 digit account ID or *", accountID)
+		}
+
+		if err := validateRoleArn(roleArn); err != nil {
+			return err
+		}
+	}
+
+	retryMaxAttempts := defaultRetryMaxAttempts
+	if c.RetryMaxAttempts != 0 {
+		if c.RetryMaxAttempts < 1 {
+			return fmt.Errorf("invalid retryMaxAttempts %d: must be at least 1", c.RetryMaxAttempts)
+		}
+		retryMaxAttempts = c.RetryMaxAttempts
+	}
+
+	retryMaxDelay := defaultRetryMaxDelay
+	if c.RetryMaxDelay != "" {
+		delay, err := time.ParseDuration(c.RetryMaxDelay)
+		if err != nil {
+			return fmt.Errorf("invalid retryMaxDelay %q: %w", c.RetryMaxDelay, err)
+		}
+
+		if delay <= 0 {
+			return fmt.Errorf("invalid retryMaxDelay %q: must be positive", c.RetryMaxDelay)
+		}
+		retryMaxDelay = delay
+	}
+
+	if err := validateRateLimits(c.RateLimits); err != nil {
+		return err
+	}
+
+	if c.SharedRateLimitDir != "" {
+		if err := os.MkdirAll(c.SharedRateLimitDir, 0o700); err != nil {
+			return fmt.Errorf("invalid sharedRateLimitDir %q: %w", c.SharedRateLimitDir, err)
+		}
+	}
+
+	endpoints := Endpoints{}
+	if c.Endpoints != nil {
+		endpoints = *c.Endpoints
+	}
+
+	cfg, err := c.loadAWSConfig(ctx, endpoints)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	if !c.SkipCredentialsValidation {
+		_, err = cfg.Credentials.Retrieve(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+		}
+	}
+
+	accountID := ""
+	if !c.SkipRequestingAccountId {
+		identity, err := sts.NewFromConfig(stsConfig(cfg, endpoints)).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return fmt.Errorf("failed to get the AWS account ID: %w", err)
+		}
+		accountID = aws.ToString(identity.Account)
+	}
+
+	configureTaggingClients(cfg, endpoints, c.S3ForcePathStyle, accountID, c.AccountRoles)
+	configureRetries(retryMaxAttempts, retryMaxDelay)
+	configureRateLimits(c.RateLimits, c.SharedRateLimitDir)
+
+	return nil
+}
+
+// loadAWSConfig loads the shared config and the default credential chain, which covers environment variables, SSO,
+// credential_process and the instance metadata service, unless static credentials are set.
+func (c *Config) loadAWSConfig(ctx context.Context, endpoints Endpoints) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{}
+
+	if c.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(c.Profile))
+	}
+
+	if len(c.SharedConfigFiles) > 0 {
+		opts = append(opts, config.WithSharedConfigFiles(c.SharedConfigFiles), config.WithSharedCredentialsFiles(c.SharedConfigFiles))
+	}
+
+	if c.Region != "" {
+		opts = append(opts, config.WithRegion(c.Region))
+	}
+
+	if c.AccessKey != "" {
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(c.AccessKey, c.SecretKey, c.Token)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	if c.AssumeRole != nil {
+		cfg.Credentials = aws.NewCredentialsCache(c.AssumeRole.credentials(sts.NewFromConfig(stsConfig(cfg, endpoints))))
+	}
+
+	return cfg, nil
+}
+
+// AssumeRole describes a role the provider assumes before making any tagging calls.
+type AssumeRole struct {
+	RoleArn     string            `pulumi:"roleArn"`
+	ExternalId  string            `pulumi:"externalId,optional"`
+	SessionName string            `pulumi:"sessionName,optional"`
+	Duration    string            `pulumi:"duration,optional"`
+	Tags        map[string]string `pulumi:"tags,optional"`
+}
+
+func (r *AssumeRole) Annotate(a infer.Annotator) {
+	a.Describe(&r.RoleArn, "The ARN of the role to assume.")
+	a.Describe(&r.ExternalId, "The external ID to pass when assuming the role.")
+	a.Describe(&r.SessionName, "The session name to use when assuming the role.")
+	a.Describe(&r.Duration, "The duration of the role session, e.g. 1h or 30m. Must be between 15m and 12h.")
+	a.Describe(&r.Tags, "Session tags to pass when assuming the role.")
+}
+
+// validate checks the role settings that would otherwise only fail once the role is first assumed.
+func (r *AssumeRole) validate() error {
+	if err := validateRoleArn(r.RoleArn); err != nil {
+		return err
+	}
+
+	if r.Duration != "" {
+		duration, err := time.ParseDuration(r.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid assumeRole duration %q: %w", r.Duration, err)
+		}
+
+		if duration < 15*time.Minute || duration > 12*time.Hour {
+			return fmt.Errorf("invalid assumeRole duration %q: must be between 15m and 12h", r.Duration)
+		}
+	}
+
+	return nil
+}
+
+// credentials returns credentials for the role, assumed with the STS client.
+func (r *AssumeRole) credentials(client *sts.Client) *stscreds.AssumeRoleProvider {
+	return stscreds.NewAssumeRoleProvider(client, r.RoleArn, func(o *stscreds.AssumeRoleOptions) {
+		if r.ExternalId != "" {
+			o.ExternalID = aws.String(r.ExternalId)
+		}
+
+		if r.SessionName != "" {
+			o.RoleSessionName = r.SessionName
+		}
+
+		if r.Duration != "" {
+			// The duration has already been validated by Configure.
+			o.Duration, _ = time.ParseDuration(r.Duration)
+		}
+
+		for key, value := range r.Tags {
+			o.Tags = append(o.Tags, stsTypes.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+	})
+}
+
+func validateRoleArn(roleArn string) error {
+	arn, err := awsArn.Parse(roleArn)
+	if err != nil {
+		return fmt.Errorf("invalid role ARN %q: %w", roleArn, err)
+	}
+
+	if arn.Service != "iam" || !strings.HasPrefix(arn.Resource, "role/") {
+		return fmt.Errorf("invalid role ARN %q: not an IAM role", roleArn)
+	}
+
+	return nil
+}
+
+// stsConfig returns the config for STS clients, falling back to us-east-1 when the config has no region, e.g. when it
+// is only set per ARN.
+func stsConfig(cfg aws.Config, endpoints Endpoints) aws.Config {
+	cfg = serviceConfig(cfg, endpoints.Sts)
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return cfg
+}
+
+// serviceConfig returns a copy of the config for the clients of one service, using the endpoint if it is overridden.
+func serviceConfig(cfg aws.Config, endpoint string) aws.Config {
+	cfg = cfg.Copy()
+	if endpoint != "" {
+		cfg.BaseEndpoint = aws.String(endpoint)
+	}
+
+	return cfg
+}
+
+// Endpoints overrides the endpoint used for each AWS API, see serviceConfig.
+type Endpoints struct {
+	Tagging string `pulumi:"tagging,optional"`
+	Sts     string `pulumi:"sts,optional"`
+	S3      string `pulumi:"s3,optional"`
+}
+
+func (e *Endpoints) Annotate(a infer.Annotator) {
+	a.Describe(&e.Tagging, "The endpoint for the Resource Groups Tagging API.")
+	a.Describe(&e.Sts, "The endpoint for STS, used to look up the account ID and to assume roles.")
+	a.Describe(&e.S3, "The endpoint for S3, used to look up the region of buckets since their ARNs don't include it.")
+}
+
+func (e *Endpoints) overrides() map[string]string {
+	return map[string]string{
+		"tagging": e.Tagging,
+		"sts":     e.Sts,
+		"s3":      e.S3,
+	}
+}
+
+func (e *Endpoints) validate() error {
+	for service, endpoint := range e.overrides() {
+		if endpoint == "" {
+			continue
+		}
+
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid %s endpoint %q: %w", service, endpoint, err)
+		}
+
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s endpoint %q: must be an http or https URL", service, endpoint)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package aws
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/nitrictech/pulumi-awstags-native/provider/mutex"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+)
+
+const testAccountID = "123456789012"
+
+// fakeTaggingAPI serves the Tagging API from memory. A resource exists once it has tags, and GetResources without a
+// list of ARNs searches every resource.
+// Operations the tests don't use aren't implemented and panic through the nil embedded interface.
+type fakeTaggingAPI struct {
+	taggingAPI
+
+	sync.Mutex
+	tags map[string]map[string]string
+	// failures are reported in the FailedResourcesMap of every TagResources and UntagResources call for the ARN.
+	failures map[string]types.FailureInfo
+	// failWrite, if set, can fail a TagResources or UntagResources call as a whole.
+	failWrite func(arns []string) error
+	// writes are the TagResources and UntagResources calls, in order.
+	writes []fakeWrite
+}
+
+// fakeWrite is a TagResources call with tags, or an UntagResources call with tagKeys.
+type fakeWrite struct {
+	arns    []string
+	tags    map[string]string
+	tagKeys []string
+}
+
+// useFakeAPI makes every tagging client use a new fake, with retries and rate limits that don't slow the tests down.
+func useFakeAPI(t *testing.T) *fakeTaggingAPI {
+	fake := &fakeTaggingAPI{
+		tags:     map[string]map[string]string{},
+		failures: map[string]types.FailureInfo{},
+	}
+
+	newAPI := newTaggingAPI
+	newTaggingAPI = func(aws.Config) taggingAPI { return fake }
+
+	unlimited := map[string]RateLimit{}
+	for operation := range defaultRateLimits {
+		unlimited[operation] = RateLimit{Rate: 1000, Burst: 1000}
+	}
+
+	configureTaggingClients(aws.Config{Region: "us-east-1"}, Endpoints{}, false, testAccountID, nil)
+	configureRetries(3, time.Millisecond)
+	configureRateLimits(unlimited, "")
+
+	t.Cleanup(func() {
+		newTaggingAPI = newAPI
+		resetTaggingClients()
+		configureRetries(defaultRetryMaxAttempts, defaultRetryMaxDelay)
+		configureRateLimits(nil, "")
+		mutex.Reset()
+	})
+
+	return fake
+}
+
+// resetTaggingClients drops the clients using the fake, so the base config is loaded again on first use.
+func resetTaggingClients() {
+	tagClients.Lock()
+	defer tagClients.Unlock()
+
+	tagClients.baseConfig = nil
+	tagClients.endpoints = Endpoints{}
+	tagClients.s3UsePathStyle = false
+	tagClients.accountID = ""
+	tagClients.accountRoles = nil
+	tagClients.clients = make(map[taggingClientKey]*taggingClient)
+
+	resetBucketRegions()
+}
+
+func (f *fakeTaggingAPI) setTags(arn string, tags map[string]string) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.tags[arn] = maps.Clone(tags)
+}
+
+func (f *fakeTaggingAPI) getTags(arn string) map[string]string {
+	f.Lock()
+	defer f.Unlock()
+
+	return maps.Clone(f.tags[arn])
+}
+
+func (f *fakeTaggingAPI) getWrites() []fakeWrite {
+	f.Lock()
+	defer f.Unlock()
+
+	return slices.Clone(f.writes)
+}
+
+func (f *fakeTaggingAPI) GetResources(ctx context.Context, input *resourcegroupstaggingapi.GetResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	arns := input.ResourceARNList
+	if len(arns) == 0 {
+		arns = sortedKeys(f.tags)
+	}
+
+	out := &resourcegroupstaggingapi.GetResourcesOutput{}
+	for _, arn := range arns {
+		tags, ok := f.tags[arn]
+		if !ok || !matchesTagFilters(tags, input.TagFilters) {
+			continue
+		}
+
+		mapping := types.ResourceTagMapping{ResourceARN: aws.String(arn)}
+		for key, value := range tags {
+			mapping.Tags = append(mapping.Tags, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		out.ResourceTagMappingList = append(out.ResourceTagMappingList, mapping)
+	}
+
+	return out, nil
+}
+
+// matchesTagFilters reports whether the tags match every filter. ResourceTypeFilters aren't supported by the fake.
+func matchesTagFilters(tags map[string]string, filters []types.TagFilter) bool {
+	for _, filter := range filters {
+		value, ok := tags[aws.ToString(filter.Key)]
+		if !ok || (len(filter.Values) > 0 && !slices.Contains(filter.Values, value)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *fakeTaggingAPI) TagResources(ctx context.Context, input *resourcegroupstaggingapi.TagResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.TagResourcesOutput, error) {
+	failed, err := f.write(fakeWrite{arns: input.ResourceARNList, tags: input.Tags}, func(tags map[string]string) {
+		for key, value := range input.Tags {
+			tags[key] = value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resourcegroupstaggingapi.TagResourcesOutput{FailedResourcesMap: failed}, nil
+}
+
+func (f *fakeTaggingAPI) UntagResources(ctx context.Context, input *resourcegroupstaggingapi.UntagResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.UntagResourcesOutput, error) {
+	failed, err := f.write(fakeWrite{arns: input.ResourceARNList, tagKeys: input.TagKeys}, func(tags map[string]string) {
+		for _, key := range input.TagKeys {
+			delete(tags, key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resourcegroupstaggingapi.UntagResourcesOutput{FailedResourcesMap: failed}, nil
+}
+
+// write records the call and applies the change to every ARN that isn't set to fail.
+func (f *fakeTaggingAPI) write(call fakeWrite, change func(tags map[string]string)) (map[string]types.FailureInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	call.arns = slices.Clone(call.arns)
+	call.tags = maps.Clone(call.tags)
+	call.tagKeys = slices.Clone(call.tagKeys)
+	f.writes = append(f.writes, call)
+
+	if f.failWrite != nil {
+		if err := f.failWrite(call.arns); err != nil {
+			return nil, err
+		}
+	}
+
+	failed := map[string]types.FailureInfo{}
+	for _, arn := range call.arns {
+		if info, ok := f.failures[arn]; ok {
+			failed[arn] = info
+			continue
+		}
+
+		if f.tags[arn] == nil {
+			f.tags[arn] = map[string]string{}
+		}
+		change(f.tags[arn])
+	}
+
+	return failed, nil
+}
+
+// testContext is a p.Context that discards log messages.
+type testContext struct {
+	context.Context
+}
+
+func newTestContext() p.Context {
+	return testContext{Context: context.Background()}
+}
+
+func (testContext) Log(severity diag.Severity, msg string)                     {}
+func (testContext) Logf(severity diag.Severity, msg string, args ...any)       {}
+func (testContext) LogStatus(severity diag.Severity, msg string)               {}
+func (testContext) LogStatusf(severity diag.Severity, msg string, args ...any) {}
+func (testContext) RuntimeInformation() p.RunInfo                              { return p.RunInfo{} }
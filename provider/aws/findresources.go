@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// FindResources searches for resources by their tags and types across regions, following every page of GetResources.
+type FindResources struct{}
+
+func (f *FindResources) Annotate(a infer.Annotator) {
+	a.Describe(&f, "Find the resources matching tag and resource type filters, together with their tags.")
+}
+
+type FindResourcesArgs struct {
+	TagFilters          []TagFilter `pulumi:"tagFilters,optional"`
+	ResourceTypeFilters []string    `pulumi:"resourceTypeFilters,optional"`
+	Regions             []string    `pulumi:"regions,optional"`
+	ResourcesPerPage    int         `pulumi:"resourcesPerPage,optional"`
+	AssumeRoleArn       string      `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *FindResourcesArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.TagFilters, "Only resources with all of these tags are returned.")
+	a.Describe(&r.ResourceTypeFilters, "Only resources of these types are returned, e.g. ec2:instance or s3.")
+	a.Describe(&r.Regions, "The regions to search. Defaults to the region of the provider.")
+	a.Describe(&r.ResourcesPerPage, "The number of resources requested per page, between 1 and 100. Every page is always read.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when searching for the resources.")
+}
+
+type FoundResource struct {
+	ResourceARN string            `pulumi:"resourceARN"`
+	Tags        map[string]string `pulumi:"tags"`
+}
+
+func (r *FoundResource) Annotate(a infer.Annotator) {
+	a.Describe(&r.ResourceARN, "The ARN of the resource.")
+	a.Describe(&r.Tags, "The tags on the resource.")
+}
+
+type FindResourcesResult struct {
+	Resources []FoundResource `pulumi:"resources"`
+}
+
+func (r *FindResourcesResult) Annotate(a infer.Annotator) {
+	a.Describe(&r.Resources, "The matching resources, sorted by ARN.")
+}
+
+func (FindResources) Call(ctx p.Context, args FindResourcesArgs) (FindResourcesResult, error) {
+	if args.ResourcesPerPage < 0 || args.ResourcesPerPage > maxReadResources {
+		return FindResourcesResult{}, fmt.Errorf("resourcesPerPage must be between 1 and %d", maxReadResources)
+	}
+
+	input := resourcegroupstaggingapi.GetResourcesInput{
+		TagFilters: toTagFilters(args.TagFilters),
+	}
+	if len(args.ResourceTypeFilters) > 0 {
+		input.ResourceTypeFilters = args.ResourceTypeFilters
+	}
+	if args.ResourcesPerPage > 0 {
+		input.ResourcesPerPage = aws.Int32(int32(args.ResourcesPerPage))
+	}
+
+	mappings, err := searchRegions(ctx, args.Regions, args.AssumeRoleArn, input)
+	if err != nil {
+		return FindResourcesResult{}, err
+	}
+
+	found := map[string]FoundResource{}
+	for _, mapping := range mappings {
+		arn := aws.ToString(mapping.ResourceARN)
+		found[arn] = FoundResource{ResourceARN: arn, Tags: mappingTags(mapping)}
+	}
+
+	// Global resources can be returned by more than one region, so they are only listed once.
+	resources := make([]FoundResource, 0, len(found))
+	for _, arn := range sortedKeys(found) {
+		resources = append(resources, found[arn])
+	}
+
+	return FindResourcesResult{Resources: resources}, nil
+}
@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// GetComplianceSummary returns the number of resources that don't comply with the tag policies of an organization.
+// It has to be called with credentials for the organization's management account.
+type GetComplianceSummary struct{}
+
+func (f *GetComplianceSummary) Annotate(a infer.Annotator) {
+	a.Describe(&f, "Get the number of resources that don't comply with the effective tag policies of an organization. "+
+		"Must be called with credentials for the organization's management account.")
+}
+
+type GetComplianceSummaryArgs struct {
+	TargetIdFilters     []string `pulumi:"targetIdFilters,optional"`
+	RegionFilters       []string `pulumi:"regionFilters,optional"`
+	ResourceTypeFilters []string `pulumi:"resourceTypeFilters,optional"`
+	TagKeyFilters       []string `pulumi:"tagKeyFilters,optional"`
+	GroupBy             []string `pulumi:"groupBy,optional"`
+	MaxResults          int      `pulumi:"maxResults,optional"`
+	AssumeRoleArn       string   `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *GetComplianceSummaryArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.TargetIdFilters, "Only summarize these targets: account IDs, organizational unit IDs or the root ID.")
+	a.Describe(&r.RegionFilters, "Only summarize resources in these regions.")
+	a.Describe(&r.ResourceTypeFilters, "Only summarize resources of these types, e.g. ec2:instance or s3.")
+	a.Describe(&r.TagKeyFilters, "Only summarize resources that are non-compliant for these tag keys.")
+	a.Describe(&r.GroupBy, "The attributes to group the summaries by: TARGET_ID, REGION and/or RESOURCE_TYPE.")
+	a.Describe(&r.MaxResults, "The number of summaries requested per page, between 1 and 100. Every page is always read.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when reading the compliance summary.")
+}
+
+type ComplianceSummary struct {
+	TargetId              string `pulumi:"targetId,optional"`
+	TargetIdType          string `pulumi:"targetIdType,optional"`
+	Region                string `pulumi:"region,optional"`
+	ResourceType          string `pulumi:"resourceType,optional"`
+	NonCompliantResources int    `pulumi:"nonCompliantResources"`
+	LastUpdated           string `pulumi:"lastUpdated,optional"`
+}
+
+func (r *ComplianceSummary) Annotate(a infer.Annotator) {
+	a.Describe(&r.TargetId, "The account, organizational unit or root the summary is for, when grouped by TARGET_ID.")
+	a.Describe(&r.TargetIdType, "The type of targetId: ACCOUNT, OU or ROOT.")
+	a.Describe(&r.Region, "The region the summary is for, when grouped by REGION.")
+	a.Describe(&r.ResourceType, "The resource type the summary is for, when grouped by RESOURCE_TYPE.")
+	a.Describe(&r.NonCompliantResources, "The number of resources that don't comply with the tag policies.")
+	a.Describe(&r.LastUpdated, "When the summary was last updated by AWS.")
+}
+
+type GetComplianceSummaryResult struct {
+	Summaries                  []ComplianceSummary `pulumi:"summaries"`
+	TotalNonCompliantResources int                 `pulumi:"totalNonCompliantResources"`
+}
+
+func (r *GetComplianceSummaryResult) Annotate(a infer.Annotator) {
+	a.Describe(&r.Summaries, "The summaries for each group.")
+	a.Describe(&r.TotalNonCompliantResources, "The number of non-compliant resources across every summary.")
+}
+
+var complianceGroupByAttributes = []string{
+	string(types.GroupByAttributeTargetId),
+	string(types.GroupByAttributeRegion),
+	string(types.GroupByAttributeResourceType),
+}
+
+func (GetComplianceSummary) Call(ctx p.Context, args GetComplianceSummaryArgs) (GetComplianceSummaryResult, error) {
+	for _, groupBy := range args.GroupBy {
+		if !slices.Contains(complianceGroupByAttributes, groupBy) {
+			return GetComplianceSummaryResult{}, fmt.Errorf("invalid groupBy %q: must be one of %s", groupBy, quoteAll(complianceGroupByAttributes))
+		}
+	}
+
+	if args.MaxResults < 0 || args.MaxResults > maxReadResources {
+		return GetComplianceSummaryResult{}, fmt.Errorf("maxResults must be between 1 and %d", maxReadResources)
+	}
+
+	// The API rejects empty filter lists, so unset filters are left out of the request.
+	input := resourcegroupstaggingapi.GetComplianceSummaryInput{}
+	if len(args.TargetIdFilters) > 0 {
+		input.TargetIdFilters = args.TargetIdFilters
+	}
+	if len(args.RegionFilters) > 0 {
+		input.RegionFilters = args.RegionFilters
+	}
+	if len(args.ResourceTypeFilters) > 0 {
+		input.ResourceTypeFilters = args.ResourceTypeFilters
+	}
+	if len(args.TagKeyFilters) > 0 {
+		input.TagKeyFilters = args.TagKeyFilters
+	}
+	if len(args.GroupBy) > 0 {
+		for _, groupBy := range args.GroupBy {
+			input.GroupBy = append(input.GroupBy, types.GroupByAttribute(groupBy))
+		}
+	}
+	if args.MaxResults > 0 {
+		input.MaxResults = aws.Int32(int32(args.MaxResults))
+	}
+
+	summaries, err := getComplianceSummaries(ctx, args.AssumeRoleArn, input)
+	if err != nil {
+		return GetComplianceSummaryResult{}, err
+	}
+
+	result := GetComplianceSummaryResult{Summaries: make([]ComplianceSummary, 0, len(summaries))}
+	for _, summary := range summaries {
+		nonCompliant := int(summary.NonCompliantResources)
+
+		result.Summaries = append(result.Summaries, ComplianceSummary{
+			TargetId:              aws.ToString(summary.TargetId),
+			TargetIdType:          string(summary.TargetIdType),
+			Region:                aws.ToString(summary.Region),
+			ResourceType:          aws.ToString(summary.ResourceType),
+			NonCompliantResources: nonCompliant,
+			LastUpdated:           aws.ToString(summary.LastUpdated),
+		})
+		result.TotalNonCompliantResources += nonCompliant
+	}
+
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// resourceTagType is the type token of ResourceTag, as used in import files.
+const resourceTagType = "awstags:aws:ResourceTag"
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// GetImportFile scans existing tags and builds a `pulumi import --file` document with a ResourceTag for each of them,
+// so manually created tags can be adopted in one step.
+type GetImportFile struct{}
+
+func (f *GetImportFile) Annotate(a infer.Annotator) {
+	a.Describe(&f, "Build a `pulumi import --file` document that imports every existing tag on the given resources, "+
+		"or on the resources matching the filters, as a ResourceTag.")
+}
+
+type GetImportFileArgs struct {
+	ResourceARNs        []string    `pulumi:"resourceARNs,optional"`
+	TagFilters          []TagFilter `pulumi:"tagFilters,optional"`
+	ResourceTypeFilters []string    `pulumi:"resourceTypeFilters,optional"`
+	Regions             []string    `pulumi:"regions,optional"`
+	TagKeys             []string    `pulumi:"tagKeys,optional"`
+	AssumeRoleArn       string      `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *GetImportFileArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.ResourceARNs, "The ARNs of the resources to import the tags of. Can't be combined with the filters.")
+	a.Describe(&r.TagFilters, "Import the tags of the resources with all of these tags.")
+	a.Describe(&r.ResourceTypeFilters, "Import the tags of the resources of these types, e.g. ec2:instance or s3.")
+	a.Describe(&r.Regions, "The regions to search when using filters. Defaults to the region of the provider.")
+	a.Describe(&r.TagKeys, "Only import tags with these keys. Defaults to every tag except the reserved aws: keys.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when reading the tags.")
+}
+
+type ImportResource struct {
+	Type string `pulumi:"type" json:"type"`
+	Name string `pulumi:"name" json:"name"`
+	ID   string `pulumi:"id" json:"id"`
+}
+
+func (r *ImportResource) Annotate(a infer.Annotator) {
+	a.Describe(&r.Type, "The type token of the resource.")
+	a.Describe(&r.Name, "The name of the resource in the program.")
+	a.Describe(&r.ID, "The ID of the tag, in the format <arn>|<key>.")
+}
+
+type GetImportFileResult struct {
+	Resources []ImportResource `pulumi:"resources"`
+	File      string           `pulumi:"file"`
+}
+
+func (r *GetImportFileResult) Annotate(a infer.Annotator) {
+	a.Describe(&r.Resources, "A ResourceTag for each existing tag, sorted by ARN and key.")
+	a.Describe(&r.File, "The JSON document to pass to `pulumi import --file`.")
+}
+
+func (GetImportFile) Call(ctx p.Context, args GetImportFileArgs) (GetImportFileResult, error) {
+	live, err := scanImportTags(ctx, args)
+	if err != nil {
+		return GetImportFileResult{}, err
+	}
+
+	resources := []ImportResource{}
+	names := map[string]bool{}
+	for _, arn := range sortedKeys(live) {
+		for _, key := range sortedKeys(live[arn]) {
+			// Keys with the aws: prefix are reserved for AWS and can't be managed.
+			if isReservedTagKey(key) {
+				continue
+			}
+
+			if len(args.TagKeys) > 0 && !slices.Contains(args.TagKeys, key) {
+				continue
+			}
+
+			resources = append(resources, ImportResource{
+				Type: resourceTagType,
+				Name: importResourceName(names, arn, key),
+				ID:   resourceTagID(arn, key),
+			})
+		}
+	}
+
+	file, err := json.MarshalIndent(map[string][]ImportResource{"resources": resources}, "", "  ")
+	if err != nil {
+		return GetImportFileResult{}, err
+	}
+
+	return GetImportFileResult{Resources: resources, File: string(file)}, nil
+}
+
+// scanImportTags returns the live tags of the listed resources, or of every resource matching the filters.
+func scanImportTags(ctx context.Context, args GetImportFileArgs) (map[string]map[string]string, error) {
+	hasFilters := len(args.TagFilters) > 0 || len(args.ResourceTypeFilters) > 0
+
+	if len(args.ResourceARNs) > 0 {
+		if hasFilters {
+			return nil, fmt.Errorf("resourceARNs can't be combined with tagFilters or resourceTypeFilters")
+		}
+
+		return getResourcesTags(ctx, args.ResourceARNs, args.AssumeRoleArn)
+	}
+
+	if !hasFilters {
+		return nil, fmt.Errorf("one of resourceARNs, tagFilters or resourceTypeFilters must be set")
+	}
+
+	input := resourcegroupstaggingapi.GetResourcesInput{
+		TagFilters: toTagFilters(args.TagFilters),
+	}
+	if len(args.ResourceTypeFilters) > 0 {
+		input.ResourceTypeFilters = args.ResourceTypeFilters
+	}
+
+	mappings, err := searchRegions(ctx, args.Regions, args.AssumeRoleArn, input)
+	if err != nil {
+		return nil, err
+	}
+
+	live := map[string]map[string]string{}
+	for _, mapping := range mappings {
+		live[aws.ToString(mapping.ResourceARN)] = mappingTags(mapping)
+	}
+
+	return live, nil
+}
+
+// importResourceName returns a unique name for the tag made from the end of the ARN and the key, e.g. my-bucket-Env.
+// names holds the names issued so far. A repeated name gets the first free -N suffix, which also has to differ from names
+// made from other keys, e.g. env-2.
+func importResourceName(names map[string]bool, arn string, key string) string {
+	resource := arn[strings.LastIndexAny(arn, ":/")+1:]
+	name := strings.Trim(invalidNameCharacters.ReplaceAllString(resource+"-"+key, "-"), "-")
+	if name == "" {
+		name = "tag"
+	}
+
+	unique := name
+	for n := 2; names[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	names[unique] = true
+
+	return unique
+}
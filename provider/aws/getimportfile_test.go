@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportResourceName(t *testing.T) {
+	names := map[string]bool{}
+
+	assert.Equal(t, "my-bucket-env", importResourceName(names, "arn:aws:s3:::my-bucket", "env"))
+	assert.Equal(t, "my-bucket-env-2", importResourceName(names, "arn:aws:s3:::my-bucket", "env"))
+	assert.Equal(t, "tagger-team-owner", importResourceName(names, "arn:aws:iam::123456789012:role/path/tagger", "team:owner"))
+	assert.Equal(t, "tag", importResourceName(names, "arn:aws:s3:::", "@@"))
+
+	// The same function in two regions, and a key that looks like a suffix.
+	names = map[string]bool{}
+	assert.Equal(t, "x-env", importResourceName(names, "arn:aws:lambda:us-east-1:123456789012:function:x", "env"))
+	assert.Equal(t, "x-env-2", importResourceName(names, "arn:aws:lambda:us-west-2:123456789012:function:x", "env"))
+	assert.Equal(t, "x-env-2-2", importResourceName(names, "arn:aws:lambda:us-east-1:123456789012:function:x", "env-2"))
+
+	names = map[string]bool{}
+	assert.Equal(t, "x-env-2", importResourceName(names, "arn:aws:lambda:us-east-1:123456789012:function:x", "env-2"))
+	assert.Equal(t, "x-env", importResourceName(names, "arn:aws:lambda:us-east-1:123456789012:function:x", "env"))
+	assert.Equal(t, "x-env-3", importResourceName(names, "arn:aws:lambda:us-west-2:123456789012:function:x", "env"))
+}
+
+func TestGetImportFile(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:import-file"
+	fake.setTags(arn, map[string]string{
+		"env":                           "prod",
+		"aws:cloudformation:stack-name": "stack",
+		"AWS:reserved":                  "value",
+	})
+
+	result, err := GetImportFile{}.Call(newTestContext(), GetImportFileArgs{ResourceARNs: []string{arn}})
+	require.NoError(t, err)
+	assert.Equal(t, []ImportResource{{Type: resourceTagType, Name: "import-file-env", ID: arn + "|env"}}, result.Resources)
+	assert.JSONEq(t, `{"resources": [{"type": "awstags:aws:ResourceTag", "name": "import-file-env", "id": "`+arn+`|env"}]}`, result.File)
+}
@@ -0,0 +1,40 @@
+package aws
+
+import (
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// GetResourceTags returns all of the live tags on a resource.
+type GetResourceTags struct{}
+
+func (f *GetResourceTags) Annotate(a infer.Annotator) {
+	a.Describe(&f, "Get all of the tags currently set on a resource.")
+}
+
+type GetResourceTagsArgs struct {
+	ResourceARN   string `pulumi:"resourceARN"`
+	AssumeRoleArn string `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *GetResourceTagsArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.ResourceARN, "The ARN of the resource.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when reading the tags.")
+}
+
+type GetResourceTagsResult struct {
+	Tags map[string]string `pulumi:"tags"`
+}
+
+func (r *GetResourceTagsResult) Annotate(a infer.Annotator) {
+	a.Describe(&r.Tags, "The tags on the resource, empty if the resource can't be found.")
+}
+
+func (GetResourceTags) Call(ctx p.Context, args GetResourceTagsArgs) (GetResourceTagsResult, error) {
+	tags, err := getTags(ctx, args.ResourceARN, args.AssumeRoleArn)
+	if err != nil {
+		return GetResourceTagsResult{}, err
+	}
+
+	return GetResourceTagsResult{Tags: tags}, nil
+}
@@ -0,0 +1,40 @@
+package aws
+
+import (
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// GetTagKeys returns the distinct tag keys used by the resources in one or more regions.
+type GetTagKeys struct{}
+
+func (f *GetTagKeys) Annotate(a infer.Annotator) {
+	a.Describe(&f, "Get the tag keys used by the resources in one or more regions.")
+}
+
+type GetTagKeysArgs struct {
+	Regions       []string `pulumi:"regions,optional"`
+	AssumeRoleArn string   `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *GetTagKeysArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.Regions, "The regions to read. Defaults to the region of the provider.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when reading the tag keys.")
+}
+
+type GetTagKeysResult struct {
+	Keys []string `pulumi:"keys"`
+}
+
+func (r *GetTagKeysResult) Annotate(a infer.Annotator) {
+	a.Describe(&r.Keys, "The distinct tag keys across all of the regions, sorted.")
+}
+
+func (GetTagKeys) Call(ctx p.Context, args GetTagKeysArgs) (GetTagKeysResult, error) {
+	keys, err := getTagKeys(ctx, args.Regions, args.AssumeRoleArn)
+	if err != nil {
+		return GetTagKeysResult{}, err
+	}
+
+	return GetTagKeysResult{Keys: keys}, nil
+}
@@ -0,0 +1,42 @@
+package aws
+
+import (
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// GetTagValues returns the distinct values of a tag key used by the resources in one or more regions.
+type GetTagValues struct{}
+
+func (f *GetTagValues) Annotate(a infer.Annotator) {
+	a.Describe(&f, "Get the values of a tag key used by the resources in one or more regions.")
+}
+
+type GetTagValuesArgs struct {
+	Key           string   `pulumi:"key"`
+	Regions       []string `pulumi:"regions,optional"`
+	AssumeRoleArn string   `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *GetTagValuesArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.Key, "The tag key to read the values of.")
+	a.Describe(&r.Regions, "The regions to read. Defaults to the region of the provider.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when reading the tag values.")
+}
+
+type GetTagValuesResult struct {
+	Values []string `pulumi:"values"`
+}
+
+func (r *GetTagValuesResult) Annotate(a infer.Annotator) {
+	a.Describe(&r.Values, "The distinct values of the tag key across all of the regions, sorted.")
+}
+
+func (GetTagValues) Call(ctx p.Context, args GetTagValuesArgs) (GetTagValuesResult, error) {
+	values, err := getTagValues(ctx, args.Regions, args.AssumeRoleArn, args.Key)
+	if err != nil {
+		return GetTagValuesResult{}, err
+	}
+
+	return GetTagValuesResult{Values: values}, nil
+}
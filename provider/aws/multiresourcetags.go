@@ -0,0 +1,230 @@
+package aws
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/nitrictech/pulumi-awstags-native/provider/mutex"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// MultiResourceTags applies the same tags to a list of resources. The resources are grouped by region and role, and
+// tagged in batches of up to 20 ARNs per call.
+type MultiResourceTags struct{}
+
+type MultiResourceTagsArgs struct {
+	ResourceARNs  []string          `pulumi:"resourceARNs"`
+	Tags          map[string]string `pulumi:"tags"`
+	AssumeRoleArn string            `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *MultiResourceTagsArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.ResourceARNs, "The ARNs of the resources to tag.")
+	a.Describe(&r.Tags, "The tags to set on every resource.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when tagging the resources.")
+}
+
+type MultiResourceTagsState struct {
+	MultiResourceTagsArgs
+}
+
+func (MultiResourceTags) Create(ctx p.Context, name string, input MultiResourceTagsArgs, preview bool) (string, MultiResourceTagsState, error) {
+	state := MultiResourceTagsState{MultiResourceTagsArgs: input}
+
+	// A repeated ARN would be sent twice, taking two of the resources a call can write.
+	arns := uniqueARNs(input.ResourceARNs)
+
+	release, err := borrowResourcesTags(arns, sortedKeys(input.Tags))
+	if err != nil {
+		return "", state, err
+	}
+
+	if preview {
+		release(true)
+		return name, state, nil
+	}
+
+	err = tagResources(ctx, arns, input.AssumeRoleArn, input.Tags)
+	release(err == nil)
+	if err != nil {
+		return "", state, err
+	}
+
+	return name, state, nil
+}
+
+func (MultiResourceTags) Read(ctx p.Context, id string, inputs MultiResourceTagsArgs, state MultiResourceTagsState) (string, MultiResourceTagsArgs, MultiResourceTagsState, error) {
+	tagged, err := taggedResources(ctx, state.ResourceARNs, state.AssumeRoleArn, state.Tags)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	inputs.ResourceARNs = tagged
+	inputs.Tags = state.Tags
+	inputs.AssumeRoleArn = state.AssumeRoleArn
+	state.MultiResourceTagsArgs = inputs
+
+	return id, inputs, state, nil
+}
+
+func (MultiResourceTags) Delete(ctx p.Context, id string, state MultiResourceTagsState) error {
+	return removeResourcesTags(ctx, state.ResourceARNs, state.AssumeRoleArn, sortedKeys(state.Tags))
+}
+
+func (MultiResourceTags) Update(ctx p.Context, id string, old MultiResourceTagsState, new MultiResourceTagsArgs, preview bool) (MultiResourceTagsState, error) {
+	state := MultiResourceTagsState{MultiResourceTagsArgs: new}
+
+	arns := uniqueARNs(new.ResourceARNs)
+
+	added, removed, kept := diffResources(uniqueARNs(old.ResourceARNs), arns)
+	removedKeys, changed := diffTagValues(old.Tags, new.Tags)
+
+	if !preview {
+		err := removeResourcesTags(ctx, removed, old.AssumeRoleArn, sortedKeys(old.Tags))
+		if err != nil {
+			return old, err
+		}
+
+		err = removeResourcesTags(ctx, kept, old.AssumeRoleArn, removedKeys)
+		if err != nil {
+			return old, err
+		}
+	}
+
+	release, err := borrowResourcesTags(arns, sortedKeys(new.Tags))
+	if err != nil {
+		return old, err
+	}
+
+	if preview {
+		release(true)
+		return state, nil
+	}
+
+	err = tagResources(ctx, added, new.AssumeRoleArn, new.Tags)
+	if err == nil {
+		err = tagResources(ctx, kept, new.AssumeRoleArn, changed)
+	}
+	release(err == nil)
+	if err != nil {
+		return old, err
+	}
+
+	return state, nil
+}
+
+// diffResources returns the ARNs that were added, removed, and kept between the old and new lists, which must not
+// contain duplicates.
+func diffResources(old, new []string) ([]string, []string, []string) {
+	added, removed, kept := []string{}, []string{}, []string{}
+
+	for _, arn := range new {
+		if slices.Contains(old, arn) {
+			kept = append(kept, arn)
+		} else {
+			added = append(added, arn)
+		}
+	}
+
+	for _, arn := range old {
+		if !slices.Contains(new, arn) {
+			removed = append(removed, arn)
+		}
+	}
+
+	return added, removed, kept
+}
+
+// taggedResources returns the ARNs that still have every tag with the same value. Resources missing any of the tags are
+// dropped from the state so the next update tags them again.
+func taggedResources(ctx context.Context, arns []string, roleArn string, tags map[string]string) ([]string, error) {
+	live, err := getResourcesTags(ctx, arns, roleArn)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := []string{}
+	for _, arn := range arns {
+		if hasTags(live[arn], tags) {
+			tagged = append(tagged, arn)
+		}
+	}
+
+	return tagged, nil
+}
+
+// hasTags reports whether every tag is set on the resource with the same value.
+func hasTags(live map[string]string, tags map[string]string) bool {
+	for key, value := range tags {
+		if liveValue, ok := live[key]; !ok || liveValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// borrowResourcesTags provides a lease on the tags for each of the ARNs, see mutex.BorrowTags.
+// The ARNs are borrowed in sorted order so concurrent callers can't deadlock.
+func borrowResourcesTags(arns []string, tagKeys []string) (func(isWriteOp bool), error) {
+	sorted := uniqueARNs(arns)
+
+	releases := make([]func(isWriteOp bool), 0, len(sorted))
+	release := func(isWriteOp bool) {
+		for _, release := range releases {
+			release(isWriteOp)
+		}
+	}
+
+	for _, arn := range sorted {
+		releaseTags, err := mutex.BorrowTags(arn, tagKeys)
+		if err != nil {
+			release(false)
+			return nil, err
+		}
+		releases = append(releases, releaseTags)
+	}
+
+	return release, nil
+}
+
+// removeResourcesTags removes the tag keys from each resource, skipping tags that a write operation has already been
+// registered for. Resources with the same set of removable keys share UntagResources calls.
+func removeResourcesTags(ctx context.Context, arns []string, roleArn string, tagKeys []string) error {
+	if len(tagKeys) == 0 {
+		return nil
+	}
+
+	sorted := uniqueARNs(arns)
+
+	groups := map[string][]string{}
+	groupKeys := map[string][]string{}
+	for _, arn := range sorted {
+		release, removable := mutex.BorrowRemovableTags(arn, tagKeys)
+		defer release(false)
+
+		group := strings.Join(removable, "\x00")
+		groups[group] = append(groups[group], arn)
+		groupKeys[group] = removable
+	}
+
+	for _, group := range sortedKeys(groups) {
+		err := untagResources(ctx, groups[group], roleArn, groupKeys[group])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniqueARNs returns the ARNs sorted and without duplicates. The tag leases aren't reentrant, so borrowing the tags of
+// the same ARN twice would block forever.
+func uniqueARNs(arns []string) []string {
+	sorted := append([]string{}, arns...)
+	slices.Sort(sorted)
+
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nitrictech/pulumi-awstags-native/provider/mutex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiResourceTagsCreateRemovesDuplicateARNs(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("multi-create", 2)
+	args := MultiResourceTagsArgs{ResourceARNs: []string{arns[1], arns[0], arns[1]}, Tags: map[string]string{"env": "prod"}}
+
+	_, state, err := MultiResourceTags{}.Create(newTestContext(), "tags", args, false)
+	require.NoError(t, err)
+	assert.Equal(t, args, state.MultiResourceTagsArgs)
+	assert.Equal(t, []fakeWrite{{arns: arns, tags: args.Tags}}, fake.getWrites())
+}
+
+func TestMultiResourceTagsUpdate(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("multi-update", 3)
+	removed, kept, added := arns[0], arns[1], arns[2]
+	fake.setTags(removed, map[string]string{"env": "prod", "team": "a", "other": "x"})
+	fake.setTags(kept, map[string]string{"env": "prod", "team": "a"})
+
+	old := MultiResourceTagsState{MultiResourceTagsArgs: MultiResourceTagsArgs{
+		ResourceARNs: []string{removed, kept},
+		Tags:         map[string]string{"env": "prod", "team": "a"},
+	}}
+	new := MultiResourceTagsArgs{
+		ResourceARNs: []string{kept, added, added},
+		Tags:         map[string]string{"env": "prod", "team": "b"},
+	}
+
+	_, err := MultiResourceTags{}.Update(newTestContext(), "tags", old, new, false)
+	require.NoError(t, err)
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{removed}, tagKeys: []string{"env", "team"}},
+		{arns: []string{added}, tags: new.Tags},
+		{arns: []string{kept}, tags: map[string]string{"team": "b"}},
+	}, fake.getWrites())
+	assert.Equal(t, map[string]string{"other": "x"}, fake.getTags(removed))
+	assert.Equal(t, new.Tags, fake.getTags(kept))
+	assert.Equal(t, new.Tags, fake.getTags(added))
+}
+
+func TestMultiResourceTagsUpdateRemovesKeys(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("multi-keys", 2)
+	for _, arn := range arns {
+		fake.setTags(arn, map[string]string{"env": "prod", "team": "a"})
+	}
+
+	old := MultiResourceTagsState{MultiResourceTagsArgs: MultiResourceTagsArgs{
+		ResourceARNs: arns,
+		Tags:         map[string]string{"env": "prod", "team": "a"},
+	}}
+	new := MultiResourceTagsArgs{ResourceARNs: arns, Tags: map[string]string{"env": "prod"}}
+
+	_, err := MultiResourceTags{}.Update(newTestContext(), "tags", old, new, false)
+	require.NoError(t, err)
+	assert.Equal(t, []fakeWrite{{arns: arns, tagKeys: []string{"team"}}}, fake.getWrites())
+}
+
+func TestDiffResources(t *testing.T) {
+	tests := []struct {
+		name                 string
+		old, new             []string
+		added, removed, kept []string
+	}{
+		{name: "empty", added: []string{}, removed: []string{}, kept: []string{}},
+		{name: "unchanged", old: []string{"a", "b"}, new: []string{"b", "a"}, added: []string{}, removed: []string{}, kept: []string{"b", "a"}},
+		{name: "added", old: []string{"a"}, new: []string{"a", "b"}, added: []string{"b"}, removed: []string{}, kept: []string{"a"}},
+		{name: "removed", old: []string{"a", "b"}, new: []string{"b"}, added: []string{}, removed: []string{"a"}, kept: []string{"b"}},
+		{name: "replaced", old: []string{"a", "b"}, new: []string{"b", "c"}, added: []string{"c"}, removed: []string{"a"}, kept: []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed, kept := diffResources(tt.old, tt.new)
+			assert.Equal(t, tt.added, added)
+			assert.Equal(t, tt.removed, removed)
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+}
+
+func TestRemoveResourcesTagsGroupsByRemovableKeys(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("multi-remove", 3)
+	for _, arn := range arns {
+		fake.setTags(arn, map[string]string{"env": "prod", "team": "a"})
+	}
+
+	// Another resource in the program writes the team tag of the second resource, so it's kept.
+	release, err := mutex.BorrowTag(arns[1], "team")
+	require.NoError(t, err)
+	release(true)
+
+	err = removeResourcesTags(context.Background(), []string{arns[2], arns[1], arns[0], arns[2]}, "", []string{"env", "team"})
+	require.NoError(t, err)
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{arns[1]}, tagKeys: []string{"env"}},
+		{arns: []string{arns[0], arns[2]}, tagKeys: []string{"env", "team"}},
+	}, fake.getWrites())
+	assert.Equal(t, map[string]string{"team": "a"}, fake.getTags(arns[1]))
+}
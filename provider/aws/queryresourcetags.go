@@ -0,0 +1,254 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// QueryResourceTags applies tags to every resource matching a GetResources query, instead of a list of ARNs.
+// The matched ARNs are kept in the state, so resources that start or stop matching are tagged or untagged on the next
+// update.
+type QueryResourceTags struct{}
+
+type TagFilter struct {
+	Key    string   `pulumi:"key"`
+	Values []string `pulumi:"values,optional"`
+}
+
+func (f *TagFilter) Annotate(a infer.Annotator) {
+	a.Describe(&f.Key, "The tag key to match.")
+	a.Describe(&f.Values, "The tag values to match. Any value matches if empty.")
+}
+
+type QueryResourceTagsArgs struct {
+	TagFilters          []TagFilter       `pulumi:"tagFilters,optional"`
+	ResourceTypeFilters []string          `pulumi:"resourceTypeFilters,optional"`
+	Regions             []string          `pulumi:"regions,optional"`
+	Tags                map[string]string `pulumi:"tags"`
+	AssumeRoleArn       string            `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *QueryResourceTagsArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.TagFilters, "Only resources with all of these tags are matched.")
+	a.Describe(&r.ResourceTypeFilters, "Only resources of these types are matched, e.g. ec2:instance or s3.")
+	a.Describe(&r.Regions, "The regions to search. Defaults to the region of the provider.")
+	a.Describe(&r.Tags, "The tags to set on every matched resource.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when searching for and tagging the resources.")
+}
+
+type QueryResourceTagsState struct {
+	QueryResourceTagsArgs
+	MatchedARNs []string `pulumi:"matchedARNs"`
+}
+
+func (r *QueryResourceTagsState) Annotate(a infer.Annotator) {
+	a.Describe(&r.MatchedARNs, "The ARNs of the resources that matched the query and were tagged.")
+}
+
+// Check requires a filter, so a query that would match every resource is reported during preview instead of at apply
+// time. Filters that are unknown during preview are checked once they are known.
+func (QueryResourceTags) Check(ctx p.Context, name string, oldInputs resource.PropertyMap, newInputs resource.PropertyMap) (QueryResourceTagsArgs, []p.CheckFailure, error) {
+	args, failures, err := infer.DefaultCheck[QueryResourceTagsArgs](newInputs)
+	if err != nil || len(failures) > 0 {
+		return args, failures, err
+	}
+
+	if isUnknownInput(newInputs, "tagFilters") || isUnknownInput(newInputs, "resourceTypeFilters") {
+		return args, nil, nil
+	}
+
+	if err := validateQuery(args); err != nil {
+		failures = append(failures, p.CheckFailure{Property: "tagFilters", Reason: err.Error()})
+	}
+
+	return args, failures, nil
+}
+
+func (QueryResourceTags) Create(ctx p.Context, name string, input QueryResourceTagsArgs, preview bool) (string, QueryResourceTagsState, error) {
+	state := QueryResourceTagsState{QueryResourceTagsArgs: input, MatchedARNs: []string{}}
+
+	if preview {
+		return name, state, nil
+	}
+
+	matched, err := queryResources(ctx, input)
+	if err != nil {
+		return "", state, err
+	}
+
+	release, err := borrowResourcesTags(matched, sortedKeys(input.Tags))
+	if err != nil {
+		return "", state, err
+	}
+
+	err = tagResources(ctx, matched, input.AssumeRoleArn, input.Tags)
+	release(err == nil)
+	if err != nil {
+		return "", state, err
+	}
+
+	state.MatchedARNs = matched
+
+	return name, state, nil
+}
+
+func (QueryResourceTags) Read(ctx p.Context, id string, inputs QueryResourceTagsArgs, state QueryResourceTagsState) (string, QueryResourceTagsArgs, QueryResourceTagsState, error) {
+	tagged, err := taggedResources(ctx, state.MatchedARNs, state.AssumeRoleArn, state.Tags)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	state.MatchedARNs = tagged
+
+	return id, state.QueryResourceTagsArgs, state, nil
+}
+
+func (QueryResourceTags) Delete(ctx p.Context, id string, state QueryResourceTagsState) error {
+	return removeResourcesTags(ctx, state.MatchedARNs, state.AssumeRoleArn, sortedKeys(state.Tags))
+}
+
+func (QueryResourceTags) Update(ctx p.Context, id string, old QueryResourceTagsState, new QueryResourceTagsArgs, preview bool) (QueryResourceTagsState, error) {
+	state := QueryResourceTagsState{QueryResourceTagsArgs: new, MatchedARNs: old.MatchedARNs}
+
+	if preview {
+		return state, nil
+	}
+
+	matched, err := queryResources(ctx, new)
+	if err != nil {
+		return old, err
+	}
+
+	added, removed, kept := diffResources(old.MatchedARNs, matched)
+	removedKeys, changed := diffTagValues(old.Tags, new.Tags)
+
+	err = removeResourcesTags(ctx, removed, old.AssumeRoleArn, sortedKeys(old.Tags))
+	if err != nil {
+		return old, err
+	}
+
+	err = removeResourcesTags(ctx, kept, old.AssumeRoleArn, removedKeys)
+	if err != nil {
+		return old, err
+	}
+
+	release, err := borrowResourcesTags(matched, sortedKeys(new.Tags))
+	if err != nil {
+		return old, err
+	}
+
+	err = tagResources(ctx, added, new.AssumeRoleArn, new.Tags)
+	if err == nil {
+		err = tagResources(ctx, kept, new.AssumeRoleArn, changed)
+	}
+	release(err == nil)
+	if err != nil {
+		return old, err
+	}
+
+	state.MatchedARNs = matched
+
+	return state, nil
+}
+
+// Diff runs the query again, so resources that started or stopped matching are reported as a change to matchedARNs.
+func (QueryResourceTags) Diff(ctx p.Context, id string, olds QueryResourceTagsState, news QueryResourceTagsArgs) (p.DiffResponse, error) {
+	diff := map[string]p.PropertyDiff{}
+
+	if !reflect.DeepEqual(olds.TagFilters, news.TagFilters) {
+		diff["tagFilters"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if !slices.Equal(olds.ResourceTypeFilters, news.ResourceTypeFilters) {
+		diff["resourceTypeFilters"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if !slices.Equal(olds.Regions, news.Regions) {
+		diff["regions"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if !reflect.DeepEqual(olds.Tags, news.Tags) {
+		diff["tags"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if olds.AssumeRoleArn != news.AssumeRoleArn {
+		diff["assumeRoleArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	matched, err := queryResources(ctx, news)
+	if err != nil {
+		return p.DiffResponse{}, err
+	}
+
+	if !slices.Equal(olds.MatchedARNs, matched) {
+		diff["matchedARNs"] = p.PropertyDiff{Kind: p.Update}
+	}
+
+	return p.DiffResponse{
+		HasChanges:   len(diff) > 0,
+		DetailedDiff: diff,
+	}, nil
+}
+
+// queryResources returns the sorted ARNs of the resources matching the query in each of its regions.
+func queryResources(ctx context.Context, args QueryResourceTagsArgs) ([]string, error) {
+	if err := validateQuery(args); err != nil {
+		return nil, err
+	}
+
+	input := resourcegroupstaggingapi.GetResourcesInput{
+		TagFilters: toTagFilters(args.TagFilters),
+	}
+	if len(args.ResourceTypeFilters) > 0 {
+		input.ResourceTypeFilters = args.ResourceTypeFilters
+	}
+
+	mappings, err := searchRegions(ctx, args.Regions, args.AssumeRoleArn, input)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []string{}
+	for _, mapping := range mappings {
+		matched = append(matched, aws.ToString(mapping.ResourceARN))
+	}
+
+	slices.Sort(matched)
+
+	return slices.Compact(matched), nil
+}
+
+// validateQuery requires at least one filter, GetResources would otherwise return every resource in the region.
+func validateQuery(args QueryResourceTagsArgs) error {
+	if len(args.TagFilters) == 0 && len(args.ResourceTypeFilters) == 0 {
+		return fmt.Errorf("at least one of tagFilters or resourceTypeFilters must be set")
+	}
+
+	return nil
+}
+
+func toTagFilters(filters []TagFilter) []types.TagFilter {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	tagFilters := make([]types.TagFilter, 0, len(filters))
+	for _, filter := range filters {
+		tagFilter := types.TagFilter{Key: aws.String(filter.Key)}
+		if len(filter.Values) > 0 {
+			tagFilter.Values = filter.Values
+		}
+		tagFilters = append(tagFilters, tagFilter)
+	}
+
+	return tagFilters
+}
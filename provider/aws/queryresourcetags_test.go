@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResourceTagsCheck(t *testing.T) {
+	unknown := resource.MakeComputed(resource.NewStringProperty(""))
+
+	tests := []struct {
+		name   string
+		inputs resource.PropertyMap
+		failed bool
+	}{
+		{
+			name:   "tag filters",
+			inputs: resource.NewPropertyMapFromMap(map[string]interface{}{"tagFilters": []interface{}{map[string]interface{}{"key": "app"}}, "tags": map[string]interface{}{}}),
+		},
+		{
+			name:   "resource type filters",
+			inputs: resource.NewPropertyMapFromMap(map[string]interface{}{"resourceTypeFilters": []interface{}{"s3"}, "tags": map[string]interface{}{}}),
+		},
+		{
+			name:   "unknown filters",
+			inputs: resource.PropertyMap{"tagFilters": unknown, "tags": resource.NewObjectProperty(resource.PropertyMap{})},
+		},
+		{
+			name:   "no filters",
+			inputs: resource.NewPropertyMapFromMap(map[string]interface{}{"regions": []interface{}{"us-east-1"}, "tags": map[string]interface{}{}}),
+			failed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, failures, err := QueryResourceTags{}.Check(newTestContext(), "query", nil, tt.inputs)
+			require.NoError(t, err)
+
+			if !tt.failed {
+				assert.Empty(t, failures)
+				return
+			}
+
+			require.Len(t, failures, 1)
+			assert.Equal(t, "tagFilters", failures[0].Property)
+			assert.Contains(t, failures[0].Reason, "at least one of tagFilters or resourceTypeFilters must be set")
+		})
+	}
+}
+
+func TestQueryResourceTagsUpdate(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arns := testARNs("query-update", 3)
+	kept, removed, added := arns[0], arns[1], arns[2]
+	fake.setTags(kept, map[string]string{"app": "web", "env": "prod", "team": "a"})
+	fake.setTags(removed, map[string]string{"app": "api", "env": "prod", "team": "a"})
+	fake.setTags(added, map[string]string{"app": "web"})
+
+	query := QueryResourceTagsArgs{
+		TagFilters: []TagFilter{{Key: "app", Values: []string{"web"}}},
+		Tags:       map[string]string{"env": "prod", "team": "a"},
+	}
+	old := QueryResourceTagsState{QueryResourceTagsArgs: query, MatchedARNs: []string{kept, removed}}
+	new := query
+	new.Tags = map[string]string{"env": "prod", "team": "b"}
+
+	state, err := QueryResourceTags{}.Update(newTestContext(), "query", old, new, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{kept, added}, state.MatchedARNs)
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{removed}, tagKeys: []string{"env", "team"}},
+		{arns: []string{added}, tags: new.Tags},
+		{arns: []string{kept}, tags: map[string]string{"team": "b"}},
+	}, fake.getWrites())
+	assert.Equal(t, map[string]string{"app": "api"}, fake.getTags(removed))
+	assert.Equal(t, map[string]string{"app": "web", "env": "prod", "team": "b"}, fake.getTags(kept))
+	assert.Equal(t, map[string]string{"app": "web", "env": "prod", "team": "b"}, fake.getTags(added))
+}
+
+func TestQueryResourceTagsUpdatePreview(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	old := QueryResourceTagsState{
+		QueryResourceTagsArgs: QueryResourceTagsArgs{TagFilters: []TagFilter{{Key: "app"}}, Tags: map[string]string{"env": "prod"}},
+		MatchedARNs:           testARNs("query-preview", 1),
+	}
+
+	state, err := QueryResourceTags{}.Update(newTestContext(), "query", old, old.QueryResourceTagsArgs, true)
+	require.NoError(t, err)
+	assert.Equal(t, old.MatchedARNs, state.MatchedARNs)
+	assert.Empty(t, fake.getWrites())
+}
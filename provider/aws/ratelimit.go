@@ -0,0 +1,181 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit is the client-side limit for calls to one Tagging API operation, per account and region.
+type RateLimit struct {
+	Rate  float64 `pulumi:"rate"`
+	Burst int     `pulumi:"burst,optional"`
+}
+
+func (r *RateLimit) Annotate(a infer.Annotator) {
+	a.Describe(&r.Rate, "The number of calls per second.")
+	a.Describe(&r.Burst, "The number of calls that can be made at once before the rate applies. Defaults to 1.")
+}
+
+// defaultRateLimits are the Tagging API quotas, which apply to each account and region separately.
+// https://docs.aws.amazon.com/tag-editor/latest/userguide/reference.html
+var defaultRateLimits = map[string]RateLimit{
+	"GetComplianceSummary": {Rate: 5, Burst: 5},
+	"GetResources":         {Rate: 15, Burst: 15},
+	"GetTagKeys":           {Rate: 15, Burst: 15},
+	"GetTagValues":         {Rate: 15, Burst: 15},
+	"TagResources":         {Rate: 5, Burst: 5},
+	"UntagResources":       {Rate: 5, Burst: 5},
+}
+
+// limiterKey identifies the quota a call counts against.
+type limiterKey struct {
+	account   string
+	region    string
+	operation string
+}
+
+var limiters = struct {
+	sync.Mutex
+	limits   map[string]RateLimit
+	registry map[limiterKey]*adaptiveLimiter
+	// sharedDir is the directory the limits are shared through with other provider processes, see reserveShared.
+	sharedDir string
+}{
+	limits:   defaultRateLimits,
+	registry: map[limiterKey]*adaptiveLimiter{},
+}
+
+const (
+	// minAdaptiveRate is the lowest rate an adaptive limiter backs off to, in calls per second.
+	minAdaptiveRate = 0.2
+	// adaptiveDecrease is the factor the rate is multiplied by after a throttling response.
+	adaptiveDecrease = 0.5
+	// adaptiveIncrease is the fraction of the configured rate added back after each successful call.
+	adaptiveIncrease = 0.05
+)
+
+// adaptiveLimiter lowers its rate when calls are throttled and slowly raises it again as calls succeed (AIMD), so
+// the provider stays under quotas that are shared with other tools in the same account.
+// The rate never goes above the configured limit.
+type adaptiveLimiter struct {
+	*rate.Limiter
+	sync.Mutex
+	key     limiterKey
+	maxRate rate.Limit
+}
+
+// wait blocks until the limit allows another call. When the limits are shared with other processes, the shared bucket
+// is used instead, falling back to the in-process limit if it can't be read.
+func (l *adaptiveLimiter) wait(ctx context.Context) error {
+	limiters.Lock()
+	sharedDir := limiters.sharedDir
+	limiters.Unlock()
+
+	if sharedDir != "" {
+		delay, err := reserveShared(sharedDir, l.key, l.Limit(), l.Burst())
+		if err == nil {
+			return sleepContext(ctx, delay)
+		}
+
+		logging.V(5).Infof("failed to use the shared rate limit of %s in %q, falling back to the in-process limit: %v",
+			l.key.operation, sharedDir, err)
+	}
+
+	return l.Wait(ctx)
+}
+
+// update adjusts the rate to the result of a call.
+func (l *adaptiveLimiter) update(throttled bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	current := l.Limit()
+
+	next := current
+	if throttled {
+		next = min(max(current*adaptiveDecrease, minAdaptiveRate), l.maxRate)
+	} else {
+		next = min(current+l.maxRate*adaptiveIncrease, l.maxRate)
+	}
+
+	if next == current {
+		return
+	}
+
+	l.SetLimit(next)
+
+	if throttled {
+		logging.V(5).Infof("%s was throttled in account %q and region %q, lowering the rate limit to %.2f calls/s",
+			l.key.operation, l.key.account, l.key.region, float64(next))
+	} else {
+		logging.V(9).Infof("raising the rate limit of %s in account %q and region %q to %.2f calls/s",
+			l.key.operation, l.key.account, l.key.region, float64(next))
+	}
+}
+
+// configureRateLimits overrides the default limits of the operations, dropping any existing limiters.
+// If sharedDir is set, the limits are shared with the other provider processes using the same directory.
+func configureRateLimits(overrides map[string]RateLimit, sharedDir string) {
+	limiters.Lock()
+	defer limiters.Unlock()
+
+	limits := make(map[string]RateLimit, len(defaultRateLimits))
+	for operation, limit := range defaultRateLimits {
+		limits[operation] = limit
+	}
+
+	for operation, limit := range overrides {
+		if limit.Burst == 0 {
+			limit.Burst = 1
+		}
+		limits[operation] = limit
+	}
+
+	limiters.limits = limits
+	limiters.registry = map[limiterKey]*adaptiveLimiter{}
+	limiters.sharedDir = sharedDir
+}
+
+// validateRateLimits checks the rate limit overrides from the provider config.
+func validateRateLimits(overrides map[string]RateLimit) error {
+	for operation, limit := range overrides {
+		if _, ok := defaultRateLimits[operation]; !ok {
+			return fmt.Errorf("invalid rateLimits key %q: must be one of %s", operation, quoteAll(sortedKeys(defaultRateLimits)))
+		}
+
+		if limit.Rate <= 0 {
+			return fmt.Errorf("invalid rate for %s: must be positive", operation)
+		}
+
+		if limit.Burst < 0 {
+			return fmt.Errorf("invalid burst for %s: must be positive", operation)
+		}
+	}
+
+	return nil
+}
+
+// getLimiter returns the limiter for calls to the operation in the account and region, creating it on first use.
+func getLimiter(account string, region string, operation string) *adaptiveLimiter {
+	limiters.Lock()
+	defer limiters.Unlock()
+
+	key := limiterKey{account: account, region: region, operation: operation}
+	if limiter, ok := limiters.registry[key]; ok {
+		return limiter
+	}
+
+	limit := limiters.limits[operation]
+	limiters.registry[key] = &adaptiveLimiter{
+		Limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst),
+		key:     key,
+		maxRate: rate.Limit(limit.Rate),
+	}
+
+	return limiters.registry[key]
+}
@@ -0,0 +1,83 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+// useRateLimits sets the rate limits for the test.
+func useRateLimits(t *testing.T, overrides map[string]RateLimit) {
+	configureRateLimits(overrides, "")
+	t.Cleanup(func() { configureRateLimits(nil, "") })
+}
+
+func TestAdaptiveLimiterUpdate(t *testing.T) {
+	useRateLimits(t, map[string]RateLimit{"TagResources": {Rate: 4}})
+
+	limiter := getLimiter(testAccountID, "us-east-1", "TagResources")
+	assert.Equal(t, 1, limiter.Burst())
+
+	limiter.update(true)
+	assert.Equal(t, rate.Limit(2), limiter.Limit())
+
+	// Each success adds back 5% of the configured rate.
+	limiter.update(false)
+	assert.InDelta(t, 2.2, float64(limiter.Limit()), 1e-9)
+
+	for i := 0; i < 10; i++ {
+		limiter.update(true)
+	}
+	assert.Equal(t, rate.Limit(minAdaptiveRate), limiter.Limit())
+
+	for i := 0; i < 100; i++ {
+		limiter.update(false)
+	}
+	assert.Equal(t, rate.Limit(4), limiter.Limit())
+}
+
+func TestAdaptiveLimiterUpdateBelowMinRate(t *testing.T) {
+	useRateLimits(t, map[string]RateLimit{"TagResources": {Rate: 0.1}})
+
+	// A configured rate below the floor is never raised by throttling.
+	limiter := getLimiter(testAccountID, "us-east-1", "TagResources")
+	limiter.update(true)
+	assert.Equal(t, rate.Limit(0.1), limiter.Limit())
+}
+
+func TestGetLimiter(t *testing.T) {
+	useRateLimits(t, nil)
+
+	limiter := getLimiter(testAccountID, "us-east-1", "GetResources")
+	assert.Same(t, limiter, getLimiter(testAccountID, "us-east-1", "GetResources"))
+	assert.NotSame(t, limiter, getLimiter(testAccountID, "eu-west-1", "GetResources"))
+	assert.NotSame(t, limiter, getLimiter("210987654321", "us-east-1", "GetResources"))
+	assert.Equal(t, rate.Limit(15), limiter.Limit())
+	assert.Equal(t, 15, limiter.Burst())
+}
+
+func TestDoneFailuresLowersRateWhenThrottled(t *testing.T) {
+	useRateLimits(t, nil)
+
+	client := &taggingClient{account: testAccountID, region: "us-east-1"}
+	limiter := getLimiter(testAccountID, "us-east-1", "TagResources")
+
+	client.doneFailures("TagResources", map[string]types.FailureInfo{"a": permanent})
+	assert.Equal(t, rate.Limit(5), limiter.Limit())
+
+	client.doneFailures("TagResources", map[string]types.FailureInfo{"a": permanent, "b": throttled})
+	assert.Equal(t, rate.Limit(2.5), limiter.Limit())
+
+	client.doneFailures("TagResources", map[string]types.FailureInfo{"a": {StatusCode: 429}})
+	assert.Equal(t, rate.Limit(1.25), limiter.Limit())
+}
+
+func TestValidateRateLimits(t *testing.T) {
+	assert.NoError(t, validateRateLimits(nil))
+	assert.NoError(t, validateRateLimits(map[string]RateLimit{"TagResources": {Rate: 1}}))
+	assert.ErrorContains(t, validateRateLimits(map[string]RateLimit{"TagResource": {Rate: 1}}), `invalid rateLimits key "TagResource"`)
+	assert.ErrorContains(t, validateRateLimits(map[string]RateLimit{"TagResources": {Rate: 0}}), "invalid rate for TagResources")
+	assert.ErrorContains(t, validateRateLimits(map[string]RateLimit{"TagResources": {Rate: 1, Burst: -1}}), "invalid burst for TagResources")
+}
@@ -0,0 +1,289 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nitrictech/pulumi-awstags-native/provider/mutex"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi-go-provider/infer"
+)
+
+// ResourceTags manages a map of tags on a single resource, writing all of them with a single call.
+// Updates only touch the keys that were added, changed or removed.
+//
+// In authoritative mode any other tags on the resource are removed as well, except for keys in the ignore lists and
+// the reserved aws: keys.
+type ResourceTags struct{}
+
+type ResourceTagsArgs struct {
+	ResourceARN       string            `pulumi:"resourceARN"`
+	Tags              map[string]string `pulumi:"tags"`
+	AssumeRoleArn     string            `pulumi:"assumeRoleArn,optional"`
+	Authoritative     bool              `pulumi:"authoritative,optional"`
+	IgnoreKeys        []string          `pulumi:"ignoreKeys,optional"`
+	IgnoreKeyPrefixes []string          `pulumi:"ignoreKeyPrefixes,optional"`
+}
+
+func (r *ResourceTagsArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.ResourceARN, "The ARN of the resource to tag.")
+	a.Describe(&r.Tags, "The tags to set on the resource.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when tagging the resource.")
+	a.Describe(&r.Authoritative, "Remove every tag on the resource that isn't in tags, except for ignored and aws: keys.")
+	a.Describe(&r.IgnoreKeys, "Tag keys that are never removed in authoritative mode.")
+	a.Describe(&r.IgnoreKeyPrefixes, "Tag key prefixes that are never removed in authoritative mode.")
+}
+
+type ResourceTagsState struct {
+	ResourceTagsArgs
+}
+
+func (ResourceTags) Create(ctx p.Context, name string, input ResourceTagsArgs, preview bool) (string, ResourceTagsState, error) {
+	state := ResourceTagsState{ResourceTagsArgs: input}
+
+	release, err := mutex.BorrowTags(input.ResourceARN, sortedKeys(input.Tags))
+	if err != nil {
+		return "", state, err
+	}
+
+	if preview {
+		release(true)
+		return name, state, nil
+	}
+
+	err = addTags(ctx, input.ResourceARN, input.AssumeRoleArn, input.Tags)
+	release(err == nil)
+	if err != nil {
+		return "", state, err
+	}
+
+	if input.Authoritative {
+		err = removeUnmanagedTags(ctx, input)
+		if err != nil {
+			return "", state, err
+		}
+	}
+
+	return name, state, nil
+}
+
+func (ResourceTags) Read(ctx p.Context, id string, inputs ResourceTagsArgs, state ResourceTagsState) (string, ResourceTagsArgs, ResourceTagsState, error) {
+	tags, err := getTags(ctx, state.ResourceARN, state.AssumeRoleArn)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	// Only the managed keys are tracked, other tags on the resource are left alone.
+	managed := map[string]string{}
+	for key := range state.Tags {
+		if value, ok := tags[key]; ok {
+			managed[key] = value
+		}
+	}
+
+	if len(managed) == 0 && len(state.Tags) > 0 {
+		// None of the tags or the resource they were attached to exist anymore, returning an empty ID removes it from the state.
+		return "", inputs, state, nil
+	}
+
+	inputs.ResourceARN = state.ResourceARN
+	inputs.AssumeRoleArn = state.AssumeRoleArn
+	inputs.Authoritative = state.Authoritative
+	inputs.IgnoreKeys = state.IgnoreKeys
+	inputs.IgnoreKeyPrefixes = state.IgnoreKeyPrefixes
+	inputs.Tags = managed
+	state.ResourceTagsArgs = inputs
+
+	return id, inputs, state, nil
+}
+
+func (ResourceTags) Delete(ctx p.Context, id string, state ResourceTagsState) error {
+	// Tags with a registered write operation are skipped, the write operation will handle them.
+	release, removable := mutex.BorrowRemovableTags(state.ResourceARN, sortedKeys(state.Tags))
+
+	err := removeTags(ctx, state.ResourceARN, state.AssumeRoleArn, removable)
+	release(false)
+
+	return err
+}
+
+func (ResourceTags) Update(ctx p.Context, id string, old ResourceTagsState, new ResourceTagsArgs, preview bool) (ResourceTagsState, error) {
+	state := ResourceTagsState{ResourceTagsArgs: new}
+
+	removed, changed := diffTags(old.ResourceTagsArgs, new)
+
+	if !preview && len(removed) > 0 {
+		release, removable := mutex.BorrowRemovableTags(old.ResourceARN, removed)
+		err := removeTags(ctx, old.ResourceARN, old.AssumeRoleArn, removable)
+		release(false)
+		if err != nil {
+			return old, err
+		}
+	}
+
+	release, err := mutex.BorrowTags(new.ResourceARN, sortedKeys(changed))
+	if err != nil {
+		return old, err
+	}
+
+	if preview {
+		release(true)
+		return state, nil
+	}
+
+	err = addTags(ctx, new.ResourceARN, new.AssumeRoleArn, changed)
+	release(err == nil)
+	if err != nil {
+		return old, err
+	}
+
+	if new.Authoritative {
+		err = removeUnmanagedTags(ctx, new)
+		if err != nil {
+			return old, err
+		}
+	}
+
+	return state, nil
+}
+
+// Diff reports changes to the inputs and, in authoritative mode, every unmanaged tag on the resource that will be removed.
+func (ResourceTags) Diff(ctx p.Context, id string, olds ResourceTagsState, news ResourceTagsArgs) (p.DiffResponse, error) {
+	diff := map[string]p.PropertyDiff{}
+
+	if olds.ResourceARN != news.ResourceARN {
+		diff["resourceARN"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if olds.AssumeRoleArn != news.AssumeRoleArn {
+		diff["assumeRoleArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if olds.Authoritative != news.Authoritative {
+		diff["authoritative"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if !slices.Equal(olds.IgnoreKeys, news.IgnoreKeys) {
+		diff["ignoreKeys"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if !slices.Equal(olds.IgnoreKeyPrefixes, news.IgnoreKeyPrefixes) {
+		diff["ignoreKeyPrefixes"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	for key, value := range news.Tags {
+		if oldValue, ok := olds.Tags[key]; !ok {
+			diff[tagPropertyPath(key)] = p.PropertyDiff{Kind: p.Add, InputDiff: true}
+		} else if oldValue != value {
+			diff[tagPropertyPath(key)] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+		}
+	}
+
+	for key := range olds.Tags {
+		if _, ok := news.Tags[key]; !ok {
+			diff[tagPropertyPath(key)] = p.PropertyDiff{Kind: p.Delete, InputDiff: true}
+		}
+	}
+
+	// The ARN is unknown during previews when it comes from a resource that hasn't been created yet.
+	if news.Authoritative && news.ResourceARN != "" {
+		unmanaged, err := getUnmanagedTagKeys(ctx, news)
+		if err != nil {
+			return p.DiffResponse{}, err
+		}
+
+		for _, key := range unmanaged {
+			diff[tagPropertyPath(key)] = p.PropertyDiff{Kind: p.Delete}
+		}
+	}
+
+	return p.DiffResponse{
+		HasChanges:   len(diff) > 0,
+		DetailedDiff: diff,
+	}, nil
+}
+
+// diffTags returns the keys to remove from the old resource and the tags to write to the new one.
+// When the ARN changes every tag moves, otherwise only added, changed and removed keys are returned.
+func diffTags(old, new ResourceTagsArgs) ([]string, map[string]string) {
+	if old.ResourceARN != new.ResourceARN {
+		return sortedKeys(old.Tags), new.Tags
+	}
+
+	return diffTagValues(old.Tags, new.Tags)
+}
+
+// diffTagValues returns the keys that were removed from old and the tags that were added or changed in new.
+func diffTagValues(old, new map[string]string) ([]string, map[string]string) {
+	removed := []string{}
+	for _, key := range sortedKeys(old) {
+		if _, ok := new[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	changed := map[string]string{}
+	for key, value := range new {
+		if oldValue, ok := old[key]; !ok || oldValue != value {
+			changed[key] = value
+		}
+	}
+
+	return removed, changed
+}
+
+// getUnmanagedTagKeys returns the keys of the live tags on the resource that aren't in args.Tags and aren't ignored.
+func getUnmanagedTagKeys(ctx context.Context, args ResourceTagsArgs) ([]string, error) {
+	live, err := getTags(ctx, args.ResourceARN, args.AssumeRoleArn)
+	if err != nil {
+		return nil, err
+	}
+
+	unmanaged := []string{}
+	for _, key := range sortedKeys(live) {
+		if _, ok := args.Tags[key]; ok || isIgnoredTagKey(args, key) {
+			continue
+		}
+		unmanaged = append(unmanaged, key)
+	}
+
+	return unmanaged, nil
+}
+
+// removeUnmanagedTags removes every tag on the resource that isn't managed by args, for authoritative mode.
+func removeUnmanagedTags(ctx context.Context, args ResourceTagsArgs) error {
+	unmanaged, err := getUnmanagedTagKeys(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	// Tags with a registered write operation are managed by another resource in the program, so they are kept.
+	release, removable := mutex.BorrowRemovableTags(args.ResourceARN, unmanaged)
+
+	err = removeTags(ctx, args.ResourceARN, args.AssumeRoleArn, removable)
+	release(false)
+
+	return err
+}
+
+func isIgnoredTagKey(args ResourceTagsArgs, key string) bool {
+	// Keys with the aws: prefix are reserved for AWS and can't be removed.
+	if isReservedTagKey(key) || slices.Contains(args.IgnoreKeys, key) {
+		return true
+	}
+
+	for _, prefix := range args.IgnoreKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tagPropertyPath returns the property path of a key in the tags map, as used by detailed diffs.
+func tagPropertyPath(key string) string {
+	return fmt.Sprintf("tags[%q]", key)
+}
@@ -0,0 +1,209 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/nitrictech/pulumi-awstags-native/provider/mutex"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTagsCreate(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:tags-create"
+	args := ResourceTagsArgs{ResourceARN: arn, Tags: map[string]string{"env": "prod", "team": "a"}}
+
+	_, state, err := ResourceTags{}.Create(newTestContext(), "tags", args, false)
+	require.NoError(t, err)
+	assert.Equal(t, args, state.ResourceTagsArgs)
+	assert.Equal(t, []fakeWrite{{arns: []string{arn}, tags: args.Tags}}, fake.getWrites())
+}
+
+func TestResourceTagsUpdateWritesOnlyChangedKeys(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:tags-update"
+	fake.setTags(arn, map[string]string{"kept": "1", "changed": "2", "removed": "3", "other": "4"})
+
+	old := ResourceTagsState{ResourceTagsArgs: ResourceTagsArgs{
+		ResourceARN: arn,
+		Tags:        map[string]string{"kept": "1", "changed": "2", "removed": "3"},
+	}}
+	new := ResourceTagsArgs{ResourceARN: arn, Tags: map[string]string{"kept": "1", "changed": "20", "added": "5"}}
+
+	state, err := ResourceTags{}.Update(newTestContext(), "tags", old, new, false)
+	require.NoError(t, err)
+	assert.Equal(t, new, state.ResourceTagsArgs)
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{arn}, tagKeys: []string{"removed"}},
+		{arns: []string{arn}, tags: map[string]string{"changed": "20", "added": "5"}},
+	}, fake.getWrites())
+	assert.Equal(t, map[string]string{"kept": "1", "changed": "20", "added": "5", "other": "4"}, fake.getTags(arn))
+}
+
+func TestResourceTagsUpdateWithoutChanges(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:tags-unchanged"
+	old := ResourceTagsState{ResourceTagsArgs: ResourceTagsArgs{ResourceARN: arn, Tags: map[string]string{"env": "prod"}}}
+
+	_, err := ResourceTags{}.Update(newTestContext(), "tags", old, old.ResourceTagsArgs, false)
+	require.NoError(t, err)
+	assert.Empty(t, fake.getWrites())
+}
+
+func TestResourceTagsUpdateMovesTags(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	from := "arn:aws:sqs:us-east-1:123456789012:tags-from"
+	to := "arn:aws:sqs:us-east-1:123456789012:tags-to"
+	fake.setTags(from, map[string]string{"env": "prod", "team": "a", "other": "b"})
+
+	old := ResourceTagsState{ResourceTagsArgs: ResourceTagsArgs{ResourceARN: from, Tags: map[string]string{"env": "prod", "team": "a"}}}
+	new := ResourceTagsArgs{ResourceARN: to, Tags: map[string]string{"env": "prod", "team": "b"}}
+
+	_, err := ResourceTags{}.Update(newTestContext(), "tags", old, new, false)
+	require.NoError(t, err)
+
+	// Every old key is removed from the old resource, even the ones with unchanged values.
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{from}, tagKeys: []string{"env", "team"}},
+		{arns: []string{to}, tags: map[string]string{"env": "prod", "team": "b"}},
+	}, fake.getWrites())
+	assert.Equal(t, map[string]string{"other": "b"}, fake.getTags(from))
+	assert.Equal(t, new.Tags, fake.getTags(to))
+}
+
+func TestResourceTagsUpdatePreview(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:tags-preview"
+	old := ResourceTagsState{ResourceTagsArgs: ResourceTagsArgs{ResourceARN: arn, Tags: map[string]string{"env": "prod", "team": "a"}}}
+	new := ResourceTagsArgs{ResourceARN: arn, Tags: map[string]string{"env": "dev"}}
+
+	_, err := ResourceTags{}.Update(newTestContext(), "tags", old, new, true)
+	require.NoError(t, err)
+	assert.Empty(t, fake.getWrites())
+}
+
+func TestDiffTags(t *testing.T) {
+	old := ResourceTagsArgs{ResourceARN: "arn:aws:s3:::old", Tags: map[string]string{"a": "1", "b": "2", "c": "3"}}
+
+	removed, changed := diffTags(old, ResourceTagsArgs{ResourceARN: old.ResourceARN, Tags: map[string]string{"a": "1", "b": "20", "d": "4"}})
+	assert.Equal(t, []string{"c"}, removed)
+	assert.Equal(t, map[string]string{"b": "20", "d": "4"}, changed)
+
+	removed, changed = diffTags(old, old)
+	assert.Empty(t, removed)
+	assert.Empty(t, changed)
+
+	removed, changed = diffTags(old, ResourceTagsArgs{ResourceARN: "arn:aws:s3:::new", Tags: map[string]string{"a": "1"}})
+	assert.Equal(t, []string{"a", "b", "c"}, removed)
+	assert.Equal(t, map[string]string{"a": "1"}, changed)
+}
+
+// authoritativeTags are the live tags of the authoritative mode tests. Only stray isn't managed, ignored or reserved.
+var authoritativeTags = map[string]string{
+	"env":                           "dev",
+	"stray":                         "x",
+	"keep":                          "y",
+	"team:owner":                    "z",
+	"AWS:upper":                     "1",
+	"Aws:mixed":                     "2",
+	"aws:cloudformation:stack-name": "stack",
+}
+
+func authoritativeArgs(arn string) ResourceTagsArgs {
+	return ResourceTagsArgs{
+		ResourceARN:       arn,
+		Tags:              map[string]string{"env": "prod"},
+		Authoritative:     true,
+		IgnoreKeys:        []string{"keep"},
+		IgnoreKeyPrefixes: []string{"team:"},
+	}
+}
+
+func TestResourceTagsCreateAuthoritative(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:authoritative-create"
+	fake.setTags(arn, authoritativeTags)
+
+	_, _, err := ResourceTags{}.Create(newTestContext(), "tags", authoritativeArgs(arn), false)
+	require.NoError(t, err)
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{arn}, tags: map[string]string{"env": "prod"}},
+		{arns: []string{arn}, tagKeys: []string{"stray"}},
+	}, fake.getWrites())
+	assert.NotContains(t, fake.getTags(arn), "stray")
+	assert.Len(t, fake.getTags(arn), len(authoritativeTags)-1)
+}
+
+func TestResourceTagsUpdateAuthoritative(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:authoritative-update"
+	fake.setTags(arn, authoritativeTags)
+
+	new := authoritativeArgs(arn)
+	old := ResourceTagsState{ResourceTagsArgs: new}
+	old.Authoritative = false
+	old.Tags = map[string]string{"env": "dev"}
+
+	_, err := ResourceTags{}.Update(newTestContext(), "tags", old, new, false)
+	require.NoError(t, err)
+	assert.Equal(t, []fakeWrite{
+		{arns: []string{arn}, tags: map[string]string{"env": "prod"}},
+		{arns: []string{arn}, tagKeys: []string{"stray"}},
+	}, fake.getWrites())
+}
+
+func TestResourceTagsAuthoritativeKeepsTagsOfOtherResources(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:authoritative-owned"
+	fake.setTags(arn, map[string]string{"stray": "x", "owned": "y"})
+
+	// Another resource in the program writes the owned tag.
+	release, err := mutex.BorrowTag(arn, "owned")
+	require.NoError(t, err)
+	release(true)
+
+	args := ResourceTagsArgs{ResourceARN: arn, Tags: map[string]string{}, Authoritative: true}
+	_, _, err = ResourceTags{}.Create(newTestContext(), "tags", args, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owned": "y"}, fake.getTags(arn))
+}
+
+func TestResourceTagsDiffAuthoritative(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:authoritative-diff"
+	fake.setTags(arn, authoritativeTags)
+
+	args := authoritativeArgs(arn)
+	resp, err := ResourceTags{}.Diff(newTestContext(), "tags", ResourceTagsState{ResourceTagsArgs: args}, args)
+	require.NoError(t, err)
+	assert.True(t, resp.HasChanges)
+	assert.Equal(t, map[string]p.PropertyDiff{`tags["stray"]`: {Kind: p.Delete}}, resp.DetailedDiff)
+
+	// Without authoritative mode the unmanaged tags aren't part of the diff.
+	args.Authoritative = false
+	resp, err = ResourceTags{}.Diff(newTestContext(), "tags", ResourceTagsState{ResourceTagsArgs: args}, args)
+	require.NoError(t, err)
+	assert.False(t, resp.HasChanges)
+}
+
+func TestIsIgnoredTagKey(t *testing.T) {
+	args := authoritativeArgs("arn:aws:s3:::bucket")
+
+	for _, key := range []string{"keep", "team:owner", "team:", "aws:cloudformation:stack-name", "AWS:upper", "Aws:mixed"} {
+		assert.True(t, isIgnoredTagKey(args, key), key)
+	}
+
+	for _, key := range []string{"stray", "env", "Keep", "Team:owner", "awsome"} {
+		assert.False(t, isIgnoredTagKey(args, key), key)
+	}
+}
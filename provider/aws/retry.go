@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	defaultRetryMaxAttempts = 5
+	defaultRetryMaxDelay    = 20 * time.Second
+	retryBaseDelay          = 200 * time.Millisecond
+)
+
+// retries is the retry policy for TagResources and UntagResources calls, set by the provider config.
+var retries = struct {
+	sync.Mutex
+	maxAttempts int
+	maxDelay    time.Duration
+}{
+	maxAttempts: defaultRetryMaxAttempts,
+	maxDelay:    defaultRetryMaxDelay,
+}
+
+// throttlingErrorCodes and transientErrorCodes are the error codes of failures that can be retried, for both failed
+// calls and the FailedResourcesMap of successful ones.
+var throttlingErrorCodes = []string{
+	"ThrottledException",
+	"ThrottlingException",
+	"Throttling",
+	"TooManyRequestsException",
+	"RequestLimitExceeded",
+}
+
+var transientErrorCodes = []string{
+	"InternalServiceException",
+	"ServiceUnavailable",
+	"RequestTimeout",
+	"RequestTimeoutException",
+}
+
+// withoutSDKRetries disables the retries of the SDK for a request, so they don't multiply with retryWrite.
+func withoutSDKRetries(o *resourcegroupstaggingapi.Options) {
+	o.Retryer = aws.NopRetryer{}
+}
+
+func configureRetries(maxAttempts int, maxDelay time.Duration) {
+	retries.Lock()
+	defer retries.Unlock()
+
+	retries.maxAttempts = maxAttempts
+	retries.maxDelay = maxDelay
+}
+
+// writeCall makes a TagResources or UntagResources call for the ARNs.
+type writeCall func(arns []string) (map[string]types.FailureInfo, error)
+
+// retryWrite makes the call for the ARNs, retrying with exponential backoff and jitter when it fails with a throttling
+// or transient error. When the call succeeds, only the ARNs that failed with a retryable error are retried.
+//
+// The error is set if the call failed for every ARN. A failed retry of only some of the ARNs is reported for each of
+// them in the FailureInfo map instead, since the other ARNs were written.
+func retryWrite(ctx context.Context, arns []string, call writeCall) (map[string]types.FailureInfo, error) {
+	retries.Lock()
+	maxAttempts, maxDelay := retries.maxAttempts, retries.maxDelay
+	retries.Unlock()
+
+	failed := map[string]types.FailureInfo{}
+	pending := arns
+
+	for attempt := 1; ; attempt++ {
+		out, err := call(pending)
+		if err != nil {
+			if attempt < maxAttempts && isRetryableError(err) {
+				if err := sleepContext(ctx, retryDelay(attempt, maxDelay)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			if len(pending) == len(arns) {
+				return nil, err
+			}
+
+			for _, arn := range pending {
+				failed[arn] = failureInfo(err)
+			}
+
+			return failed, nil
+		}
+
+		retry := []string{}
+		for arn, info := range out {
+			if attempt < maxAttempts && isRetryableFailure(info) {
+				retry = append(retry, arn)
+			} else {
+				failed[arn] = info
+			}
+		}
+
+		if len(retry) == 0 {
+			return failed, nil
+		}
+
+		pending = retry
+		if err := sleepContext(ctx, retryDelay(attempt, maxDelay)); err != nil {
+			for _, arn := range pending {
+				failed[arn] = failureInfo(err)
+			}
+
+			return failed, nil
+		}
+	}
+}
+
+// sleepContext waits for the delay, returning early with the error of the context if it is done first.
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryDelay returns a random delay of up to retryBaseDelay doubled for each attempt, capped at maxDelay.
+func retryDelay(attempt int, maxDelay time.Duration) time.Duration {
+	delay := maxDelay
+	if attempt < 32 && retryBaseDelay<<(attempt-1) < maxDelay {
+		delay = retryBaseDelay << (attempt - 1)
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+func isRetryableError(err error) bool {
+	if isThrottlingError(err) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && slices.Contains(transientErrorCodes, apiErr.ErrorCode()) {
+		return true
+	}
+
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && isRetryableStatusCode(responseErr.HTTPStatusCode()) {
+		return true
+	}
+
+	// Covers connection resets and network timeouts.
+	return retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(err) == aws.TrueTernary
+}
+
+func isThrottlingError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && slices.Contains(throttlingErrorCodes, apiErr.ErrorCode()) {
+		return true
+	}
+
+	return retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+}
+
+func isRetryableFailure(info types.FailureInfo) bool {
+	return isThrottlingFailure(info) || slices.Contains(transientErrorCodes, string(info.ErrorCode)) ||
+		isRetryableStatusCode(int(info.StatusCode))
+}
+
+func isThrottlingFailure(info types.FailureInfo) bool {
+	return slices.Contains(throttlingErrorCodes, string(info.ErrorCode)) || info.StatusCode == http.StatusTooManyRequests
+}
+
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// failureInfo describes an error in the same way as the FailedResourcesMap of TagResources and UntagResources.
+func failureInfo(err error) types.FailureInfo {
+	info := types.FailureInfo{ErrorMessage: aws.String(err.Error())}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		info.ErrorCode = types.ErrorCode(apiErr.ErrorCode())
+		info.ErrorMessage = aws.String(apiErr.ErrorMessage())
+	}
+
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		info.StatusCode = int32(responseErr.HTTPStatusCode())
+	}
+
+	return info
+}
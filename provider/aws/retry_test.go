@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useRetries sets the retry policy for the test, with delays that don't slow it down.
+func useRetries(t *testing.T, maxAttempts int) {
+	configureRetries(maxAttempts, time.Millisecond)
+	t.Cleanup(func() { configureRetries(defaultRetryMaxAttempts, defaultRetryMaxDelay) })
+}
+
+var (
+	throttled = types.FailureInfo{ErrorCode: "ThrottlingException", ErrorMessage: aws.String("rate exceeded"), StatusCode: 400}
+	permanent = types.FailureInfo{
+		ErrorCode:    types.ErrorCodeInvalidParameterException,
+		ErrorMessage: aws.String("the resource doesn't support tags"),
+		StatusCode:   400,
+	}
+)
+
+func TestRetryWriteRetriesThrottledCalls(t *testing.T) {
+	useRetries(t, 3)
+
+	calls := 0
+	failed, err := retryWrite(context.Background(), []string{"a", "b"}, func(arns []string) (map[string]types.FailureInfo, error) {
+		calls++
+		if calls == 1 {
+			return nil, &smithy.GenericAPIError{Code: "ThrottlingException", Message: "rate exceeded"}
+		}
+		assert.Equal(t, []string{"a", "b"}, arns)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRetryWriteRetriesOnlyRetryableResources(t *testing.T) {
+	useRetries(t, 3)
+
+	calls := [][]string{}
+	failed, err := retryWrite(context.Background(), []string{"a", "b", "c"}, func(arns []string) (map[string]types.FailureInfo, error) {
+		calls = append(calls, arns)
+		if len(calls) == 1 {
+			return map[string]types.FailureInfo{"a": throttled, "b": permanent}, nil
+		}
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.FailureInfo{"b": permanent}, failed)
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"a"}}, calls)
+}
+
+func TestRetryWriteStopsAtMaxAttempts(t *testing.T) {
+	useRetries(t, 3)
+
+	calls := 0
+	failed, err := retryWrite(context.Background(), []string{"a", "b"}, func(arns []string) (map[string]types.FailureInfo, error) {
+		calls++
+		return map[string]types.FailureInfo{"a": throttled}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]types.FailureInfo{"a": throttled}, failed)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	_, err = retryWrite(context.Background(), []string{"a"}, func(arns []string) (map[string]types.FailureInfo, error) {
+		calls++
+		return nil, &smithy.GenericAPIError{Code: "ServiceUnavailable"}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryWriteDoesNotRetryPermanentErrors(t *testing.T) {
+	useRetries(t, 3)
+
+	calls := 0
+	_, err := retryWrite(context.Background(), []string{"a"}, func(arns []string) (map[string]types.FailureInfo, error) {
+		calls++
+		return nil, &smithy.GenericAPIError{Code: "InvalidParameterException", Message: "invalid ARN"}
+	})
+	assert.ErrorContains(t, err, "invalid ARN")
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryWriteReportsFailedRetriesForEachResource(t *testing.T) {
+	useRetries(t, 3)
+
+	calls := 0
+	failed, err := retryWrite(context.Background(), []string{"a", "b"}, func(arns []string) (map[string]types.FailureInfo, error) {
+		calls++
+		if calls == 1 {
+			return map[string]types.FailureInfo{"a": throttled}, nil
+		}
+		return nil, &smithy.GenericAPIError{Code: "InvalidParameterException", Message: "invalid ARN"}
+	})
+	require.NoError(t, err)
+	require.Contains(t, failed, "a")
+	assert.NotContains(t, failed, "b")
+	assert.Contains(t, aws.ToString(failed["a"].ErrorMessage), "invalid ARN")
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 1; attempt <= 40; attempt++ {
+		limit := 5 * time.Second
+		if attempt < 6 {
+			limit = retryBaseDelay << (attempt - 1)
+		}
+
+		for i := 0; i < 100; i++ {
+			delay := retryDelay(attempt, 5*time.Second)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.Less(t, delay, limit, "attempt %d", attempt)
+		}
+	}
+
+	assert.Zero(t, retryDelay(1, 0))
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// reserveShared takes a token from the bucket for the key, which is stored in a file in dir so that every provider
+// process on the machine shares it. The bucket is refilled at the limit and holds at most burst tokens.
+// The tokens can go negative, so concurrent callers queue up behind each other. It returns how long the caller has to
+// wait before making its call.
+func reserveShared(dir string, key limiterKey, limit rate.Limit, burst int) (time.Duration, error) {
+	f, err := os.OpenFile(sharedBucketPath(dir, key), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if err := lockFile(f); err != nil {
+		return 0, err
+	}
+	defer unlockFile(f)
+
+	now := time.Now()
+
+	// A new or unreadable bucket starts full.
+	tokens := float64(burst)
+	var lastNanos int64
+	if _, err := fmt.Fscan(f, &tokens, &lastNanos); err == nil {
+		elapsed := max(now.Sub(time.Unix(0, lastNanos)), 0)
+		tokens = min(tokens+elapsed.Seconds()*float64(limit), float64(burst))
+	} else {
+		tokens = float64(burst)
+	}
+
+	tokens--
+
+	if err := f.Truncate(0); err != nil {
+		return 0, err
+	}
+
+	if _, err := f.WriteAt([]byte(fmt.Sprintf("%g %d", tokens, now.UnixNano())), 0); err != nil {
+		return 0, err
+	}
+
+	if tokens >= 0 {
+		return 0, nil
+	}
+
+	return time.Duration(-tokens / float64(limit) * float64(time.Second)), nil
+}
+
+func sharedBucketPath(dir string, key limiterKey) string {
+	// The account and region are empty when they come from the default credentials.
+	account, region := key.account, key.region
+	if account == "" {
+		account = "default"
+	}
+	if region == "" {
+		region = "default"
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s.bucket", account, region, key.operation))
+}
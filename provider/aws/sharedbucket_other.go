@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package aws
+
+import (
+	"errors"
+	"os"
+)
+
+// Shared rate limits aren't supported on this platform, so the in-process limits are used instead.
+func lockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
+
+func unlockFile(f *os.File) error {
+	return errors.ErrUnsupported
+}
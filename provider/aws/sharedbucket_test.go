@@ -0,0 +1,63 @@
+//go:build linux || darwin
+
+package aws
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReserveShared(t *testing.T) {
+	dir := t.TempDir()
+	key := limiterKey{account: testAccountID, region: "us-east-1", operation: "TagResources"}
+
+	// The burst is free, then each call queues up behind the previous one at the rate.
+	for _, want := range []time.Duration{0, 0, 200 * time.Millisecond, 400 * time.Millisecond} {
+		delay, err := reserveShared(dir, key, 5, 2)
+		require.NoError(t, err)
+		assert.InDelta(t, want, delay, float64(20*time.Millisecond))
+	}
+
+	// Other operations have their own bucket.
+	delay, err := reserveShared(dir, limiterKey{account: testAccountID, region: "us-east-1", operation: "UntagResources"}, 5, 2)
+	require.NoError(t, err)
+	assert.Zero(t, delay)
+}
+
+func TestReserveSharedRefills(t *testing.T) {
+	dir := t.TempDir()
+	key := limiterKey{account: testAccountID, region: "us-east-1", operation: "TagResources"}
+
+	for i := 0; i < 2; i++ {
+		_, err := reserveShared(dir, key, 100, 1)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	delay, err := reserveShared(dir, key, 100, 1)
+	require.NoError(t, err)
+	assert.Zero(t, delay)
+}
+
+func TestAdaptiveLimiterWaitUsesSharedBucket(t *testing.T) {
+	dir := t.TempDir()
+	configureRateLimits(nil, dir)
+	t.Cleanup(func() { configureRateLimits(nil, "") })
+
+	limiter := getLimiter(testAccountID, "us-east-1", "TagResources")
+	require.NoError(t, limiter.wait(context.Background()))
+	assert.FileExists(t, sharedBucketPath(dir, limiter.key))
+}
+
+func TestSharedBucketPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("dir", "123456789012_us-east-1_TagResources.bucket"),
+		sharedBucketPath("dir", limiterKey{account: testAccountID, region: "us-east-1", operation: "TagResources"}))
+	assert.Equal(t, filepath.Join("dir", "default_default_GetResources.bucket"),
+		sharedBucketPath("dir", limiterKey{operation: "GetResources"}))
+}
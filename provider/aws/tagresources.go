@@ -2,21 +2,93 @@ package aws
 
 import (
 	"context"
-	"time"
-
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awsArn "github.com/aws/aws-sdk-go-v2/aws/arn"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/nitrictech/pulumi-awstags-native/provider/mutex"
 	p "github.com/pulumi/pulumi-go-provider"
-	"golang.org/x/time/rate"
+	"github.com/pulumi/pulumi-go-provider/infer"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 )
 
-var tagClients map[string]*resourcegroupstaggingapi.ResourceGroupsTaggingAPI
+var tagClients = struct {
+	sync.Mutex
+	baseConfig     *aws.Config
+	endpoints      Endpoints
+	s3UsePathStyle bool
+	accountID      string
+	accountRoles   map[string]string
+	clients        map[taggingClientKey]*taggingClient
+}{
+	clients: make(map[taggingClientKey]*taggingClient),
+}
+
+// Tagging clients are cached per role, so resources in other accounts are tagged with that account's credentials.
+type taggingClientKey struct {
+	roleArn string
+	region  string
+}
+
+// taggingAPI is the part of the Resource Groups Tagging API used by the provider, implemented by
+// resourcegroupstaggingapi.Client.
+type taggingAPI interface {
+	GetResources(ctx context.Context, input *resourcegroupstaggingapi.GetResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetResourcesOutput, error)
+	GetTagKeys(ctx context.Context, input *resourcegroupstaggingapi.GetTagKeysInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetTagKeysOutput, error)
+	GetTagValues(ctx context.Context, input *resourcegroupstaggingapi.GetTagValuesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetTagValuesOutput, error)
+	GetComplianceSummary(ctx context.Context, input *resourcegroupstaggingapi.GetComplianceSummaryInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.GetComplianceSummaryOutput, error)
+	TagResources(ctx context.Context, input *resourcegroupstaggingapi.TagResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.TagResourcesOutput, error)
+	UntagResources(ctx context.Context, input *resourcegroupstaggingapi.UntagResourcesInput, optFns ...func(*resourcegroupstaggingapi.Options)) (*resourcegroupstaggingapi.UntagResourcesOutput, error)
+}
+
+// newTaggingAPI creates the API client of every tagging client. Tests can replace it to serve the calls without AWS.
+var newTaggingAPI = func(cfg aws.Config) taggingAPI {
+	return resourcegroupstaggingapi.NewFromConfig(cfg)
+}
+
+// taggingClient is a client for one region and set of credentials. Its calls are rate limited per account, region and
+// operation, matching the Tagging API quotas.
+type taggingClient struct {
+	api     taggingAPI
+	account string
+	region  string
+}
+
+// wait blocks until the rate limit of the operation allows another call.
+func (c *taggingClient) wait(ctx context.Context, operation string) error {
+	return getLimiter(c.account, c.region, operation).wait(ctx)
+}
+
+// done adapts the rate limit of the operation to the result of a call, see adaptiveLimiter.
+func (c *taggingClient) done(operation string, err error) {
+	getLimiter(c.account, c.region, operation).update(isThrottlingError(err))
+}
+
+// doneFailures adapts the rate limit of a write that succeeded, which can still report throttled resources in its
+// FailedResourcesMap.
+func (c *taggingClient) doneFailures(operation string, failed map[string]types.FailureInfo) {
+	throttled := false
+	for _, info := range failed {
+		throttled = throttled || isThrottlingFailure(info)
+	}
 
-// The Resource Tagging API has a rate limit of 5 requests per second. https://docs.aws.amazon.com/tag-editor/latest/userguide/reference.html
-var limiter = rate.NewLimiter(rate.Every(time.Second/5), 1)
+	getLimiter(c.account, c.region, operation).update(throttled)
+}
 
 // Each resource has a controlling struct.
 // Resource behavior is determined by implementing methods on the controlling struct.
@@ -36,14 +108,53 @@ type Tag struct {
 }
 
 type ResourceTagArgs struct {
-	ResourceARN string `pulumi:"resourceARN"`
-	Tag         Tag    `pulumi:"tag"`
+	ResourceARN   string `pulumi:"resourceARN"`
+	Tag           Tag    `pulumi:"tag"`
+	AssumeRoleArn string `pulumi:"assumeRoleArn,optional"`
+}
+
+func (r *ResourceTagArgs) Annotate(a infer.Annotator) {
+	a.Describe(&r.ResourceARN, "The ARN of the resource to tag. Changing it replaces the tag.")
+	a.Describe(&r.Tag, "The tag to set on the resource. Changing the key replaces the tag, changing the value updates it.")
+	a.Describe(&r.AssumeRoleArn, "A role to assume when tagging the resource.")
 }
 
 type ResourceTagState struct {
 	ResourceTagArgs
 }
 
+// tagPattern matches the characters AWS allows in tag keys and values: letters, numbers, spaces and _ . : / = + - @
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+// Check rejects tags and ARNs that AWS would reject, so the error is reported during preview instead of at apply
+// time. Values that are unknown during preview are checked once they are known.
+func (ResourceTag) Check(ctx p.Context, name string, oldInputs resource.PropertyMap, newInputs resource.PropertyMap) (ResourceTagArgs, []p.CheckFailure, error) {
+	args, failures, err := infer.DefaultCheck[ResourceTagArgs](newInputs)
+	if err != nil || len(failures) > 0 {
+		return args, failures, err
+	}
+
+	if isKnownInput(newInputs, "resourceARN") {
+		if _, err := awsArn.Parse(args.ResourceARN); err != nil {
+			failures = append(failures, p.CheckFailure{Property: "resourceARN", Reason: fmt.Sprintf("invalid ARN %q: %s", args.ResourceARN, err)})
+		}
+	}
+
+	if isKnownInput(newInputs, "tag", "key") {
+		if err := validateTagKey(args.Tag.Key); err != nil {
+			failures = append(failures, p.CheckFailure{Property: "tag.key", Reason: err.Error()})
+		}
+	}
+
+	if isKnownInput(newInputs, "tag", "value") {
+		if err := validateTagValue(args.Tag.Value); err != nil {
+			failures = append(failures, p.CheckFailure{Property: "tag.value", Reason: err.Error()})
+		}
+	}
+
+	return args, failures, nil
+}
+
 // All resources must implement Create at a minimum.
 func (ResourceTag) Create(ctx p.Context, name string, input ResourceTagArgs, preview bool) (string, ResourceTagState, error) {
 	state := ResourceTagState{ResourceTagArgs: input}
@@ -55,145 +166,809 @@ func (ResourceTag) Create(ctx p.Context, name string, input ResourceTagArgs, pre
 
 	if preview {
 		release(true)
-		return name, state, nil
+		return resourceTagID(input.ResourceARN, input.Tag.Key), state, nil
 	}
-	addTag(input.ResourceARN, input.Tag)
 
-	release(true)
+	err = addTag(ctx, input.ResourceARN, input.AssumeRoleArn, input.Tag)
+	release(err == nil)
+	if err != nil {
+		return "", state, err
+	}
 
-	return name, state, nil
+	return resourceTagID(input.ResourceARN, input.Tag.Key), state, nil
 }
 
-func (ResourceTag) Delete(ctx p.Context, name string, state ResourceTagState, preview bool) error {
+// Read fills in the state from the ID when a tag is imported, so existing tags can be adopted without recreating them.
+// Tags created before IDs were composite keep their name-based ID, their state already has the ARN and key.
+func (ResourceTag) Read(ctx p.Context, id string, inputs ResourceTagArgs, state ResourceTagState) (string, ResourceTagArgs, ResourceTagState, error) {
+	if state.ResourceARN == "" {
+		arn, key, err := parseResourceTagID(id)
+		if err != nil {
+			return "", inputs, state, err
+		}
+
+		state.ResourceARN = arn
+		state.Tag.Key = key
+	}
+
+	tags, err := getTags(ctx, state.ResourceARN, state.AssumeRoleArn)
+	if err != nil {
+		return "", inputs, state, err
+	}
+
+	value, ok := tags[state.Tag.Key]
+	if !ok {
+		// The tag or the resource it was attached to no longer exists, returning an empty ID removes it from the state.
+		return "", inputs, state, nil
+	}
+
+	inputs.ResourceARN = state.ResourceARN
+	inputs.AssumeRoleArn = state.AssumeRoleArn
+	inputs.Tag = Tag{Key: state.Tag.Key, Value: value}
+	state.ResourceTagArgs = inputs
+
+	return id, inputs, state, nil
+}
+
+func (ResourceTag) Delete(ctx p.Context, id string, state ResourceTagState) error {
 	release, err := mutex.BorrowTag(state.ResourceARN, state.Tag.Key)
 	if err != nil {
 		// A write operation has already been registered for the tag on the ARN. So deletion isn't needed, the write operation will handle it.
 		return nil
 	}
 
-	if preview {
-		release(false)
-		return nil
+	err = removeTag(ctx, state.ResourceARN, state.AssumeRoleArn, state.Tag.Key)
+	release(false)
+
+	return err
+}
+
+// Update only changes the value of the tag, changes to the ARN or key replace it, see Diff.
+func (ResourceTag) Update(ctx p.Context, id string, old ResourceTagState, new ResourceTagArgs, preview bool) (ResourceTagState, error) {
+	state := ResourceTagState{ResourceTagArgs: new}
+
+	release, err := mutex.BorrowTag(new.ResourceARN, new.Tag.Key)
+	if err != nil {
+		return old, err
+	}
+
+	// A change of role alone doesn't need the tag to be written again.
+	if preview || old.Tag.Value == new.Tag.Value {
+		release(true)
+		return state, nil
 	}
 
-	removeTag(state.ResourceARN, state.Tag.Key)
+	err = addTag(ctx, new.ResourceARN, new.AssumeRoleArn, new.Tag)
+	release(err == nil)
+	if err != nil {
+		return old, err
+	}
 
-	release(false)
+	return state, nil
+}
+
+// resourceTagID returns the ID of the tag with the key on the ARN, in the <arn>|<key> format accepted by pulumi import.
+func resourceTagID(arn string, key string) string {
+	return arn + "|" + key
+}
+
+func parseResourceTagID(id string) (string, string, error) {
+	// Tag keys can't contain |, so the last one separates the ARN from the key.
+	i := strings.LastIndex(id, "|")
+	if i <= 0 || i == len(id)-1 {
+		return "", "", fmt.Errorf("invalid ID %q: must be in the format <arn>|<key>", id)
+	}
+
+	return id[:i], id[i+1:], nil
+}
+
+// Diff replaces the tag when it moves to another resource or key, so the preview shows the old tag being removed.
+// Only value and role changes are updated in place. Use the deleteBeforeReplace resource option to remove the old tag
+// before the new one is added.
+func (ResourceTag) Diff(ctx p.Context, id string, olds ResourceTagState, news ResourceTagArgs) (p.DiffResponse, error) {
+	diff := map[string]p.PropertyDiff{}
+
+	if olds.ResourceARN != news.ResourceARN {
+		diff["resourceARN"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	if olds.Tag.Key != news.Tag.Key {
+		diff["tag.key"] = p.PropertyDiff{Kind: p.UpdateReplace, InputDiff: true}
+	}
+
+	if olds.Tag.Value != news.Tag.Value {
+		diff["tag.value"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	if olds.AssumeRoleArn != news.AssumeRoleArn {
+		diff["assumeRoleArn"] = p.PropertyDiff{Kind: p.Update, InputDiff: true}
+	}
+
+	return p.DiffResponse{
+		HasChanges:   len(diff) > 0,
+		DetailedDiff: diff,
+	}, nil
+}
+
+// isReservedTagKey reports whether the key has the aws: prefix, in any case, which is reserved for tags set by AWS.
+func isReservedTagKey(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), "aws:")
+}
+
+func validateTagKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("tag key can't be empty")
+	}
+
+	if utf8.RuneCountInString(key) > 128 {
+		return fmt.Errorf("invalid tag key %q: must be at most 128 characters", key)
+	}
+
+	// The prefix is reserved in any combination of upper and lowercase.
+	if isReservedTagKey(key) {
+		return fmt.Errorf("invalid tag key %q: the aws: prefix is reserved for AWS", key)
+	}
+
+	if !tagPattern.MatchString(key) {
+		return fmt.Errorf("invalid tag key %q: may only contain letters, numbers, spaces and _ . : / = + - @", key)
+	}
+
+	return nil
+}
+
+// validateTagValue doesn't include the value in its errors, since it may be a secret.
+func validateTagValue(value string) error {
+	if utf8.RuneCountInString(value) > 256 {
+		return fmt.Errorf("invalid tag value: must be at most 256 characters")
+	}
+
+	if !tagPattern.MatchString(value) {
+		return fmt.Errorf("invalid tag value: may only contain letters, numbers, spaces and _ . : / = + - @")
+	}
 
 	return nil
 }
 
-func (ResourceTag) Update(ctx p.Context, name string, old, new ResourceTagState, preview bool) error {
-	if new.ResourceARN != old.ResourceARN || new.Tag.Key != old.Tag.Key {
-		release, err := mutex.BorrowTag(old.ResourceARN, old.Tag.Key)
-		// Remove can be skipped if a write operation has already been registered for the tag on the ARN.
-		if err == nil {
-			removeTag(old.ResourceARN, old.Tag.Key)
-			release(false)
+// isKnownInput reports whether the input at the path of object keys is set to a known value. Inputs from resources
+// that haven't been created yet are unknown during preview.
+func isKnownInput(inputs resource.PropertyMap, path ...resource.PropertyKey) bool {
+	value := resource.NewObjectProperty(inputs)
+	for _, key := range path {
+		if value.IsSecret() {
+			value = value.SecretValue().Element
+		}
+
+		if !value.IsObject() {
+			return false
+		}
+
+		var ok bool
+		value, ok = value.ObjectValue()[key]
+		if !ok {
+			return false
 		}
 	}
 
-	release, err := mutex.BorrowTag(new.ResourceARN, new.Tag.Key)
+	return !value.ContainsUnknowns()
+}
+
+// isUnknownInput reports whether the top-level input is set to a value that is unknown during preview, or contains one.
+func isUnknownInput(inputs resource.PropertyMap, key resource.PropertyKey) bool {
+	value, ok := inputs[key]
+	return ok && value.ContainsUnknowns()
+}
+
+func removeTag(ctx context.Context, arn string, roleArn string, tagKey string) error {
+	return removeTags(ctx, arn, roleArn, []string{tagKey})
+}
+
+func addTag(ctx context.Context, arn string, roleArn string, tag Tag) error {
+	return addTags(ctx, arn, roleArn, map[string]string{tag.Key: tag.Value})
+}
+
+// removeTags and addTags write a single resource, concurrent writes to other resources share their calls, see
+// coalesceTags.
+func removeTags(ctx context.Context, arn string, roleArn string, tagKeys []string) error {
+	return coalesceUntags(ctx, arn, roleArn, tagKeys)
+}
+
+func addTags(ctx context.Context, arn string, roleArn string, tags map[string]string) error {
+	return coalesceTags(ctx, arn, roleArn, tags)
+}
+
+// getTags returns the live tags on the resource, or an empty map if the resource can't be found.
+func getTags(ctx context.Context, arn string, roleArn string) (map[string]string, error) {
+	tags, err := getResourcesTags(ctx, []string{arn}, roleArn)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if preview {
-		release(true)
-		return nil
+	if tags[arn] == nil {
+		return map[string]string{}, nil
 	}
 
-	addTag(new.ResourceARN, new.Tag)
+	return tags[arn], nil
+}
 
-	release(true)
+// TagResources and UntagResources accept at most 20 ARNs and 50 tags per call, GetResources accepts at most 100 ARNs.
+const (
+	maxWriteResources = 20
+	maxWriteTags      = 50
+	maxReadResources  = 100
+)
 
-	return nil
+// resourceBatch is a set of ARNs that can be sent to the Tagging API in a single call.
+type resourceBatch struct {
+	client *taggingClient
+	arns   []string
 }
 
-func removeTag(arn string, tagKey string) error {
-	region, err := getRegion(arn)
+// batchResources groups the ARNs by the client for their region and role, splitting each group into batches of at
+// most size ARNs.
+func batchResources(ctx context.Context, arns []string, roleArn string, size int) ([]resourceBatch, error) {
+	batches := []resourceBatch{}
+	// The batch currently being filled for each client.
+	current := map[*taggingClient]int{}
+
+	for _, arn := range arns {
+		client, err := getResourceTaggingClient(ctx, arn, roleArn)
+		if err != nil {
+			return nil, err
+		}
+
+		i, ok := current[client]
+		if !ok || len(batches[i].arns) == size {
+			batches = append(batches, resourceBatch{client: client})
+			i = len(batches) - 1
+			current[client] = i
+		}
+
+		batches[i].arns = append(batches[i].arns, arn)
+	}
+
+	return batches, nil
+}
+
+// untagResources removes the tag keys from every resource, using as few UntagResources calls as possible.
+func untagResources(ctx context.Context, arns []string, roleArn string, tagKeys []string) error {
+	if len(arns) == 0 || len(tagKeys) == 0 {
+		return nil
+	}
+
+	batches, err := batchResources(ctx, arns, roleArn, maxWriteResources)
 	if err != nil {
 		return err
 	}
 
-	tagClient, err := getTaggingClient(region)
+	errs := []error{}
+	for _, batch := range batches {
+		for _, keys := range chunkTagKeys(tagKeys) {
+			failed, err := untagBatch(ctx, batch, keys)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			errs = append(errs, joinResourceErrors(failed))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// tagResources adds the tags to every resource, using as few TagResources calls as possible.
+func tagResources(ctx context.Context, arns []string, roleArn string, tags map[string]string) error {
+	if len(arns) == 0 || len(tags) == 0 {
+		return nil
+	}
+
+	batches, err := batchResources(ctx, arns, roleArn, maxWriteResources)
 	if err != nil {
 		return err
 	}
 
-	err = limiter.Wait(context.Background())
+	errs := []error{}
+	for _, batch := range batches {
+		for _, keys := range chunkTagKeys(sortedKeys(tags)) {
+			failed, err := tagBatch(ctx, batch, subsetTags(tags, keys))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			errs = append(errs, joinResourceErrors(failed))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// untagBatch removes at most 50 tag keys from a batch of resources with a single UntagResources call, retrying
+// throttling and transient errors. The error is set if the call failed, otherwise the map has an error for each
+// resource that wasn't untagged.
+func untagBatch(ctx context.Context, batch resourceBatch, tagKeys []string) (map[string]error, error) {
+	failed, err := retryWrite(ctx, batch.arns, func(arns []string) (map[string]types.FailureInfo, error) {
+		err := batch.client.wait(ctx, "UntagResources")
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := batch.client.api.UntagResources(ctx, &resourcegroupstaggingapi.UntagResourcesInput{
+			ResourceARNList: arns,
+			TagKeys:         tagKeys,
+		}, withoutSDKRetries)
+		if err != nil {
+			batch.client.done("UntagResources", err)
+			return nil, err
+		}
+
+		batch.client.doneFailures("UntagResources", out.FailedResourcesMap)
+
+		return out.FailedResourcesMap, nil
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to remove %s from %s: %w", describeTagKeys(tagKeys), quoteAll(batch.arns), err)
+	}
+
+	// A resource that no longer exists has no tags left to remove, e.g. when it was deleted outside of Pulumi.
+	for arn, info := range failed {
+		if isNotFoundFailure(info) {
+			delete(failed, arn)
+		}
 	}
 
-	_, err = tagClient.UntagResources(&resourcegroupstaggingapi.UntagResourcesInput{
-		ResourceARNList: aws.StringSlice([]string{arn}),
-		TagKeys:         aws.StringSlice([]string{tagKey}),
+	return failedResourcesErrors("remove", tagKeys, failed), nil
+}
+
+// tagBatch adds at most 50 tags to a batch of resources with a single TagResources call, retrying throttling and
+// transient errors. The error is set if the call failed, otherwise the map has an error for each resource that
+// wasn't tagged.
+func tagBatch(ctx context.Context, batch resourceBatch, tags map[string]string) (map[string]error, error) {
+	tagKeys := sortedKeys(tags)
+
+	failed, err := retryWrite(ctx, batch.arns, func(arns []string) (map[string]types.FailureInfo, error) {
+		err := batch.client.wait(ctx, "TagResources")
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := batch.client.api.TagResources(ctx, &resourcegroupstaggingapi.TagResourcesInput{
+			ResourceARNList: arns,
+			Tags:            tags,
+		}, withoutSDKRetries)
+		if err != nil {
+			batch.client.done("TagResources", err)
+			return nil, err
+		}
+
+		batch.client.doneFailures("TagResources", out.FailedResourcesMap)
+
+		return out.FailedResourcesMap, nil
 	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to add %s to %s: %w", describeTagKeys(tagKeys), quoteAll(batch.arns), err)
 	}
 
-	return nil
+	return failedResourcesErrors("add", tagKeys, failed), nil
 }
 
-func addTag(arn string, tag Tag) error {
-	// Group ARNs by region so we can make a single call to each region.
-	region, err := getRegion(arn)
+// chunkTagKeys splits the sorted tag keys into chunks that fit in a single TagResources or UntagResources call.
+func chunkTagKeys(tagKeys []string) [][]string {
+	chunks := [][]string{}
+	for len(tagKeys) > maxWriteTags {
+		chunks = append(chunks, tagKeys[:maxWriteTags])
+		tagKeys = tagKeys[maxWriteTags:]
+	}
+
+	return append(chunks, tagKeys)
+}
+
+// subsetTags returns the tags with the given keys.
+func subsetTags(tags map[string]string, tagKeys []string) map[string]string {
+	subset := make(map[string]string, len(tagKeys))
+	for _, key := range tagKeys {
+		subset[key] = tags[key]
+	}
+
+	return subset
+}
+
+// getResourcesTags returns the live tags of each resource, resources that can't be found are omitted.
+func getResourcesTags(ctx context.Context, arns []string, roleArn string) (map[string]map[string]string, error) {
+	batches, err := batchResources(ctx, arns, roleArn, maxReadResources)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	tagClient, err := getTaggingClient(region)
+	tags := map[string]map[string]string{}
+	for _, batch := range batches {
+		err = batch.client.wait(ctx, "GetResources")
+		if err != nil {
+			return nil, err
+		}
+
+		out, err := batch.client.api.GetResources(ctx, &resourcegroupstaggingapi.GetResourcesInput{
+			ResourceARNList: batch.arns,
+		})
+		batch.client.done("GetResources", err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the tags of %s: %w", quoteAll(batch.arns), err)
+		}
+
+		for _, mapping := range out.ResourceTagMappingList {
+			arn := aws.ToString(mapping.ResourceARN)
+			if !slices.Contains(batch.arns, arn) {
+				continue
+			}
+
+			tags[arn] = mappingTags(mapping)
+		}
+	}
+
+	return tags, nil
+}
+
+func mappingTags(mapping types.ResourceTagMapping) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range mapping.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return tags
+}
+
+// searchRegions returns every resource in each of the regions that matches the input. An empty list of regions searches
+// the region of the provider.
+func searchRegions(ctx context.Context, regions []string, roleArn string, input resourcegroupstaggingapi.GetResourcesInput) ([]types.ResourceTagMapping, error) {
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	mappings := []types.ResourceTagMapping{}
+	for _, region := range regions {
+		regionMappings, err := searchRegion(ctx, region, roleArn, input)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, regionMappings...)
+	}
+
+	return mappings, nil
+}
+
+// searchRegion returns every resource in the region that matches the input, reading all pages of GetResources.
+func searchRegion(ctx context.Context, region string, roleArn string, input resourcegroupstaggingapi.GetResourcesInput) ([]types.ResourceTagMapping, error) {
+	tagClient, err := getTaggingClient(region, roleArn)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = limiter.Wait(context.Background())
+	mappings := []types.ResourceTagMapping{}
+	pages := resourcegroupstaggingapi.NewGetResourcesPaginator(tagClient.api, &input)
+	err = readPages(ctx, tagClient, "GetResources", pages, func(out *resourcegroupstaggingapi.GetResourcesOutput) {
+		mappings = append(mappings, out.ResourceTagMappingList...)
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to find resources in region %q: %w", region, err)
+	}
+
+	return mappings, nil
+}
+
+// getTagKeys returns the distinct tag keys used in each of the regions, sorted. An empty list of regions reads the
+// region of the provider.
+func getTagKeys(ctx context.Context, regions []string, roleArn string) ([]string, error) {
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	keys := []string{}
+	for _, region := range regions {
+		tagClient, err := getTaggingClient(region, roleArn)
+		if err != nil {
+			return nil, err
+		}
+
+		pages := resourcegroupstaggingapi.NewGetTagKeysPaginator(tagClient.api, &resourcegroupstaggingapi.GetTagKeysInput{})
+		err = readPages(ctx, tagClient, "GetTagKeys", pages, func(out *resourcegroupstaggingapi.GetTagKeysOutput) {
+			keys = append(keys, out.TagKeys...)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tag keys in region %q: %w", region, err)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return slices.Compact(keys), nil
+}
+
+// getTagValues returns the distinct values of the tag key in each of the regions, sorted. An empty list of regions
+// reads the region of the provider.
+func getTagValues(ctx context.Context, regions []string, roleArn string, key string) ([]string, error) {
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+
+	values := []string{}
+	for _, region := range regions {
+		tagClient, err := getTaggingClient(region, roleArn)
+		if err != nil {
+			return nil, err
+		}
+
+		pages := resourcegroupstaggingapi.NewGetTagValuesPaginator(tagClient.api, &resourcegroupstaggingapi.GetTagValuesInput{Key: aws.String(key)})
+		err = readPages(ctx, tagClient, "GetTagValues", pages, func(out *resourcegroupstaggingapi.GetTagValuesOutput) {
+			values = append(values, out.TagValues...)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get values of tag %q in region %q: %w", key, region, err)
+		}
 	}
 
-	_, err = tagClient.TagResources(&resourcegroupstaggingapi.TagResourcesInput{
-		ResourceARNList: aws.StringSlice([]string{arn}),
-		Tags:            aws.StringMap(map[string]string{tag.Key: tag.Value}),
+	slices.Sort(values)
+
+	return slices.Compact(values), nil
+}
+
+// getComplianceSummaries returns every summary of the tag policy compliance of an organization, reading all pages of
+// GetComplianceSummary.
+func getComplianceSummaries(ctx context.Context, roleArn string, input resourcegroupstaggingapi.GetComplianceSummaryInput) ([]types.Summary, error) {
+	// Compliance summaries can only be read from us-east-1 of the organization's management account.
+	tagClient, err := getTaggingClient("us-east-1", roleArn)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []types.Summary{}
+	pages := resourcegroupstaggingapi.NewGetComplianceSummaryPaginator(tagClient.api, &input)
+	err = readPages(ctx, tagClient, "GetComplianceSummary", pages, func(out *resourcegroupstaggingapi.GetComplianceSummaryOutput) {
+		summaries = append(summaries, out.SummaryList...)
 	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get the compliance summary: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// paginator is implemented by the paginators of the resourcegroupstaggingapi package.
+type paginator[O any] interface {
+	HasMorePages() bool
+	NextPage(ctx context.Context, optFns ...func(*resourcegroupstaggingapi.Options)) (O, error)
+}
+
+// readPages passes every page of the paginator to read, waiting for the rate limit of the operation before each page.
+func readPages[O any](ctx context.Context, client *taggingClient, operation string, pages paginator[O], read func(O)) error {
+	for pages.HasMorePages() {
+		err := client.wait(ctx, operation)
+		if err != nil {
+			return err
+		}
+
+		out, err := pages.NextPage(ctx)
+		client.done(operation, err)
+		if err != nil {
+			return err
+		}
+
+		read(out)
 	}
 
 	return nil
 }
 
-func getRegion(arnString string) (string, error) {
+// failedResourcesErrors reports the ARNs the Tagging API couldn't update. TagResources and UntagResources
+// succeed even when some or all of the resources fail, so the response has to be checked as well.
+func failedResourcesErrors(action string, tagKeys []string, failed map[string]types.FailureInfo) map[string]error {
+	errs := make(map[string]error, len(failed))
+	for arn, info := range failed {
+		errs[arn] = fmt.Errorf("failed to %s %s on %q: %s (error code: %s, status code: %d)",
+			action, describeTagKeys(tagKeys), arn, aws.ToString(info.ErrorMessage), info.ErrorCode, info.StatusCode)
+	}
+
+	return errs
+}
+
+// notFoundErrorCodes are the error codes the services behind the Tagging API report for resources that don't exist.
+// The Tagging API passes them on in the message of an InvalidParameterException.
+// NotFoundException also matches prefixed codes such as ResourceNotFoundException.
+var notFoundErrorCodes = []string{
+	"NotFoundException",
+	"NoSuchBucket",
+	"NoSuchEntity",
+}
+
+// isNotFoundFailure reports whether the resource of a FailedResourcesMap entry doesn't exist.
+func isNotFoundFailure(info types.FailureInfo) bool {
+	if info.StatusCode == http.StatusNotFound {
+		return true
+	}
+
+	message := aws.ToString(info.ErrorMessage)
+	for _, code := range notFoundErrorCodes {
+		if strings.Contains(message, code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinResourceErrors joins the errors for each resource, sorted by ARN.
+func joinResourceErrors(errs map[string]error) error {
+	joined := make([]error, 0, len(errs))
+	for _, arn := range sortedKeys(errs) {
+		joined = append(joined, errs[arn])
+	}
+
+	return errors.Join(joined...)
+}
+
+// describeTagKeys formats tag keys for error messages, e.g. `tag "a"` or `tags "a", "b"`.
+func describeTagKeys(tagKeys []string) string {
+	if len(tagKeys) == 1 {
+		return "tag " + quoteAll(tagKeys)
+	}
+
+	return "tags " + quoteAll(tagKeys)
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, strconv.Quote(value))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// configureTaggingClients replaces the config the tagging clients are created from, dropping any cached clients.
+// accountRoles maps account IDs, or * for any account other than accountID, to the role used for their resources.
+func configureTaggingClients(cfg aws.Config, endpoints Endpoints, s3UsePathStyle bool, accountID string, accountRoles map[string]string) {
+	tagClients.Lock()
+	defer tagClients.Unlock()
+
+	tagClients.baseConfig = &cfg
+	tagClients.endpoints = endpoints
+	tagClients.s3UsePathStyle = s3UsePathStyle
+	tagClients.accountID = accountID
+	tagClients.accountRoles = accountRoles
+	tagClients.clients = make(map[taggingClientKey]*taggingClient)
+
+	resetBucketRegions()
+}
+
+// getAccountRole returns the role mapped to the account in the provider config, or an empty string if resources in
+// the account should use the provider's credentials.
+func getAccountRole(accountID string) string {
+	tagClients.Lock()
+	defer tagClients.Unlock()
+
+	// Some ARNs, such as S3 buckets, don't include the account.
+	if accountID == "" {
+		return ""
+	}
+
+	if roleArn, ok := tagClients.accountRoles[accountID]; ok {
+		return roleArn
+	}
+
+	if accountID == tagClients.accountID {
+		return ""
+	}
+
+	return tagClients.accountRoles["*"]
+}
+
+// getResourceTaggingClient returns a tagging client for the region and account of the resource. Unless roleArn is
+// set, the role is looked up from the account ID in the ARN.
+func getResourceTaggingClient(ctx context.Context, arnString string, roleArn string) (*taggingClient, error) {
 	arn, err := awsArn.Parse(arnString)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// S3 bucket ARNs are regionless, so we default to us-east-1.
-	if arn.Service == "s3" {
-		return "us-east-1", nil
+	if roleArn == "" {
+		roleArn = getAccountRole(arn.AccountID)
 	}
 
-	return arn.Region, nil
+	region, err := resourceRegion(ctx, arn)
+	if err != nil {
+		return nil, err
+	}
+
+	return getTaggingClient(region, roleArn)
 }
 
-func getTaggingClient(region string) (*resourcegroupstaggingapi.ResourceGroupsTaggingAPI, error) {
-	if tagClients == nil {
-		tagClients = make(map[string]*resourcegroupstaggingapi.ResourceGroupsTaggingAPI)
-	}
+// getTaggingClient returns a tagging client for the region, using the provider's credentials or, if roleArn is set,
+// credentials for that role assumed using the provider's credentials.
+func getTaggingClient(region string, roleArn string) (*taggingClient, error) {
+	tagClients.Lock()
+	defer tagClients.Unlock()
 
-	if client, ok := tagClients[region]; ok {
+	key := taggingClientKey{roleArn: roleArn, region: region}
+	if client, ok := tagClients.clients[key]; ok {
 		return client, nil
 	}
 
-	sess := session.Must(session.NewSessionWithOptions(session.Options{
-		Config:            aws.Config{Region: aws.String(region)},
-		SharedConfigState: session.SharedConfigEnable,
-	}))
+	baseConfig, err := loadBaseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty region falls back to the region of the base config.
+	cfg := baseConfig.Copy()
+	if region != "" {
+		cfg.Region = region
+	}
+
+	if roleArn != "" {
+		if err := validateRoleArn(roleArn); err != nil {
+			return nil, err
+		}
+		stsClient := sts.NewFromConfig(stsConfig(cfg, tagClients.endpoints))
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, roleArn))
+	}
+
+	// The quotas apply to the account of the credentials, which is the provider's account unless a role is assumed.
+	account := tagClients.accountID
+	if roleArn != "" {
+		// The role ARN has already been validated.
+		arn, _ := awsArn.Parse(roleArn)
+		account = arn.AccountID
+	}
+
+	tagClients.clients[key] = &taggingClient{
+		api:     newTaggingAPI(serviceConfig(cfg, tagClients.endpoints.Tagging)),
+		account: account,
+		region:  cfg.Region,
+	}
+
+	return tagClients.clients[key], nil
+}
+
+// getS3Client returns an anonymous client for the S3 endpoint, which is only used to look up the region of buckets.
+func getS3Client() (*s3.Client, error) {
+	tagClients.Lock()
+	defer tagClients.Unlock()
 
-	tagClients[region] = resourcegroupstaggingapi.New(sess)
+	baseConfig, err := loadBaseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := serviceConfig(baseConfig, tagClients.endpoints.S3)
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.Credentials = aws.AnonymousCredentials{}
+		o.UsePathStyle = tagClients.s3UsePathStyle
+	}), nil
+}
+
+// loadBaseConfig returns the config the clients are created from. If the provider hasn't been configured, the default
+// credential chain and shared config are used. The tagClients lock must be held.
+func loadBaseConfig() (aws.Config, error) {
+	if tagClients.baseConfig == nil {
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return aws.Config{}, err
+		}
+		tagClients.baseConfig = &cfg
+	}
 
-	return tagClients[region], nil
+	return *tagClients.baseConfig, nil
 }
@@ -0,0 +1,221 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	p "github.com/pulumi/pulumi-go-provider"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTagRead(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:read"
+	fake.setTags(arn, map[string]string{"env": "prod"})
+
+	state := ResourceTagState{ResourceTagArgs: ResourceTagArgs{ResourceARN: arn, Tag: Tag{Key: "env", Value: "dev"}}}
+	id, inputs, state, err := ResourceTag{}.Read(newTestContext(), resourceTagID(arn, "env"), state.ResourceTagArgs, state)
+	require.NoError(t, err)
+	assert.Equal(t, resourceTagID(arn, "env"), id)
+	assert.Equal(t, Tag{Key: "env", Value: "prod"}, inputs.Tag)
+	assert.Equal(t, Tag{Key: "env", Value: "prod"}, state.Tag)
+}
+
+func TestResourceTagReadDropsMissingTag(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	tagged := "arn:aws:sqs:us-east-1:123456789012:untagged"
+	fake.setTags(tagged, map[string]string{"team": "a"})
+
+	for _, arn := range []string{tagged, "arn:aws:sqs:us-east-1:123456789012:deleted"} {
+		state := ResourceTagState{ResourceTagArgs: ResourceTagArgs{ResourceARN: arn, Tag: Tag{Key: "env", Value: "prod"}}}
+		id, _, _, err := ResourceTag{}.Read(newTestContext(), resourceTagID(arn, "env"), state.ResourceTagArgs, state)
+		require.NoError(t, err)
+		assert.Empty(t, id, arn)
+	}
+}
+
+func TestResourceTagReadImport(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:import"
+	fake.setTags(arn, map[string]string{"env": "prod"})
+
+	id, inputs, state, err := ResourceTag{}.Read(newTestContext(), arn+"|env", ResourceTagArgs{}, ResourceTagState{})
+	require.NoError(t, err)
+	assert.Equal(t, arn+"|env", id)
+	assert.Equal(t, ResourceTagArgs{ResourceARN: arn, Tag: Tag{Key: "env", Value: "prod"}}, inputs)
+	assert.Equal(t, inputs, state.ResourceTagArgs)
+}
+
+func TestResourceTagUpdateSkipsUnchangedValue(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:update"
+	old := ResourceTagState{ResourceTagArgs: ResourceTagArgs{ResourceARN: arn, Tag: Tag{Key: "env", Value: "prod"}}}
+	new := old.ResourceTagArgs
+	new.AssumeRoleArn = "arn:aws:iam::123456789012:role/tagger"
+
+	state, err := ResourceTag{}.Update(newTestContext(), resourceTagID(arn, "env"), old, new, false)
+	require.NoError(t, err)
+	assert.Equal(t, new, state.ResourceTagArgs)
+	assert.Empty(t, fake.getWrites())
+}
+
+func TestTagResourcesReportsFailedResources(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	ok := "arn:aws:sqs:us-east-1:123456789012:ok"
+	bad := "arn:aws:sqs:us-east-1:123456789012:bad"
+	fake.failures[bad] = types.FailureInfo{
+		ErrorCode:    types.ErrorCodeInvalidParameterException,
+		ErrorMessage: aws.String("the resource doesn't support tags"),
+		StatusCode:   400,
+	}
+
+	err := tagResources(context.Background(), []string{ok, bad}, "", map[string]string{"env": "prod"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), bad)
+	assert.Contains(t, err.Error(), "the resource doesn't support tags")
+	assert.NotContains(t, err.Error(), ok)
+	assert.Equal(t, map[string]string{"env": "prod"}, fake.getTags(ok))
+}
+
+func TestUntagResourcesIgnoresMissingResources(t *testing.T) {
+	fake := useFakeAPI(t)
+
+	arn := "arn:aws:sqs:us-east-1:123456789012:missing"
+	fake.failures[arn] = types.FailureInfo{
+		ErrorCode:    types.ErrorCodeInvalidParameterException,
+		ErrorMessage: aws.String("ResourceNotFoundException: the queue doesn't exist"),
+		StatusCode:   400,
+	}
+
+	err := untagResources(context.Background(), []string{arn}, "", []string{"env"})
+	assert.NoError(t, err)
+}
+
+func TestResourceTagCheck(t *testing.T) {
+	arn := "arn:aws:sqs:us-east-1:123456789012:check"
+
+	tests := []struct {
+		name     string
+		inputs   resource.PropertyMap
+		property string
+	}{
+		{name: "valid", inputs: tagInputs(arn, "env", "prod")},
+		{name: "invalid ARN", inputs: tagInputs("not-an-arn", "env", "prod"), property: "resourceARN"},
+		{name: "empty key", inputs: tagInputs(arn, "", "prod"), property: "tag.key"},
+		{name: "long key", inputs: tagInputs(arn, strings.Repeat("k", 129), "prod"), property: "tag.key"},
+		{name: "reserved key", inputs: tagInputs(arn, "AWS:env", "prod"), property: "tag.key"},
+		{name: "invalid key characters", inputs: tagInputs(arn, "env#1", "prod"), property: "tag.key"},
+		{name: "long value", inputs: tagInputs(arn, "env", strings.Repeat("v", 257)), property: "tag.value"},
+		{name: "invalid value characters", inputs: tagInputs(arn, "env", "a;b"), property: "tag.value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, failures, err := ResourceTag{}.Check(newTestContext(), "tag", nil, tt.inputs)
+			require.NoError(t, err)
+
+			if tt.property == "" {
+				assert.Empty(t, failures)
+				return
+			}
+
+			require.Len(t, failures, 1)
+			assert.Equal(t, tt.property, failures[0].Property)
+		})
+	}
+}
+
+func TestResourceTagCheckOmitsValue(t *testing.T) {
+	_, failures, err := ResourceTag{}.Check(newTestContext(), "tag", nil, tagInputs("arn:aws:sqs:us-east-1:123456789012:check", "env", "secret;"))
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.NotContains(t, failures[0].Reason, "secret")
+}
+
+func tagInputs(arn string, key string, value string) resource.PropertyMap {
+	return resource.NewPropertyMapFromMap(map[string]interface{}{
+		"resourceARN": arn,
+		"tag": map[string]interface{}{
+			"key":   key,
+			"value": value,
+		},
+	})
+}
+
+func TestResourceTagDiff(t *testing.T) {
+	old := ResourceTagState{ResourceTagArgs: ResourceTagArgs{
+		ResourceARN: "arn:aws:sqs:us-east-1:123456789012:diff",
+		Tag:         Tag{Key: "env", Value: "prod"},
+	}}
+
+	tests := []struct {
+		name   string
+		change func(args *ResourceTagArgs)
+		diff   map[string]p.DiffKind
+	}{
+		{
+			name:   "no change",
+			change: func(args *ResourceTagArgs) {},
+			diff:   map[string]p.DiffKind{},
+		},
+		{
+			name:   "value",
+			change: func(args *ResourceTagArgs) { args.Tag.Value = "dev" },
+			diff:   map[string]p.DiffKind{"tag.value": p.Update},
+		},
+		{
+			name:   "key",
+			change: func(args *ResourceTagArgs) { args.Tag.Key = "stage" },
+			diff:   map[string]p.DiffKind{"tag.key": p.UpdateReplace},
+		},
+		{
+			name:   "resource",
+			change: func(args *ResourceTagArgs) { args.ResourceARN = "arn:aws:sqs:us-east-1:123456789012:other" },
+			diff:   map[string]p.DiffKind{"resourceARN": p.UpdateReplace},
+		},
+		{
+			name:   "role",
+			change: func(args *ResourceTagArgs) { args.AssumeRoleArn = "arn:aws:iam::123456789012:role/tagger" },
+			diff:   map[string]p.DiffKind{"assumeRoleArn": p.Update},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			news := old.ResourceTagArgs
+			tt.change(&news)
+
+			resp, err := ResourceTag{}.Diff(newTestContext(), "id", old, news)
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.diff) > 0, resp.HasChanges)
+
+			kinds := map[string]p.DiffKind{}
+			for property, diff := range resp.DetailedDiff {
+				kinds[property] = diff.Kind
+			}
+			assert.Equal(t, tt.diff, kinds)
+		})
+	}
+}
+
+func TestParseResourceTagID(t *testing.T) {
+	arn, key, err := parseResourceTagID(resourceTagID("arn:aws:s3:::my-bucket", "team:owner"))
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:s3:::my-bucket", arn)
+	assert.Equal(t, "team:owner", key)
+
+	for _, id := range []string{"", "my-tag", "|env", "arn:aws:s3:::my-bucket|"} {
+		_, _, err := parseResourceTagID(id)
+		assert.Error(t, err, id)
+	}
+}
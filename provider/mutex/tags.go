@@ -2,6 +2,7 @@ package mutex
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -24,19 +25,86 @@ func BorrowTag(arn, tag string) (func(isWriteOp bool), error) {
 	if _, ok := tagRegistry.arnTagLocks[arn][tag]; !ok {
 		tagRegistry.arnTagLocks[arn][tag] = &sync.Mutex{}
 	}
+	tagLock := tagRegistry.arnTagLocks[arn][tag]
 	tagRegistry.Unlock()
-	tagRegistry.arnTagLocks[arn][tag].Lock()
+	tagLock.Lock()
 
+	tagRegistry.Lock()
 	if _, ok := tagRegistry.arnTags[arn]; !ok {
 		tagRegistry.arnTags[arn] = make(map[string]bool)
 	}
+	isWriteOp, ok := tagRegistry.arnTags[arn][tag]
+	tagRegistry.Unlock()
 
-	if isWriteOp, ok := tagRegistry.arnTags[arn][tag]; ok && isWriteOp {
+	if ok && isWriteOp {
+		tagLock.Unlock()
 		return nil, fmt.Errorf("a write operation has already been registered for tag %q on ARN %q", tag, arn)
 	}
 
 	return func(isWriteOp bool) {
+		tagRegistry.Lock()
 		tagRegistry.arnTags[arn][tag] = isWriteOp
-		tagRegistry.arnTagLocks[arn][tag].Lock()
+		tagRegistry.Unlock()
+		tagLock.Unlock()
 	}, nil
 }
+
+// BorrowTags provides a lease on several tags for a given ARN, see BorrowTag. The tags are borrowed in sorted order so
+// concurrent callers can't deadlock. If a write operation has already been registered for any of the tags, the leases
+// acquired so far are released and BorrowTags will return an error.
+func BorrowTags(arn string, tags []string) (func(isWriteOp bool), error) {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+
+	releases := make([]func(isWriteOp bool), 0, len(sorted))
+	release := func(isWriteOp bool) {
+		for _, release := range releases {
+			release(isWriteOp)
+		}
+	}
+
+	for _, tag := range sorted {
+		releaseTag, err := BorrowTag(arn, tag)
+		if err != nil {
+			release(false)
+			return nil, err
+		}
+		releases = append(releases, releaseTag)
+	}
+
+	return release, nil
+}
+
+// BorrowRemovableTags provides a lease on each of the tags for a given ARN that no write operation has been registered
+// for. Tags with a registered write operation will be handled by that write, so they are skipped. The tags that were
+// borrowed are returned along with the function that concludes their leases.
+func BorrowRemovableTags(arn string, tags []string) (func(isWriteOp bool), []string) {
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+
+	borrowed := make([]string, 0, len(sorted))
+	releases := make([]func(isWriteOp bool), 0, len(sorted))
+	for _, tag := range sorted {
+		releaseTag, err := BorrowTag(arn, tag)
+		if err != nil {
+			continue
+		}
+		borrowed = append(borrowed, tag)
+		releases = append(releases, releaseTag)
+	}
+
+	return func(isWriteOp bool) {
+		for _, release := range releases {
+			release(isWriteOp)
+		}
+	}, borrowed
+}
+
+// Reset drops every lease and registered write operation. Tests use it to start each case from an empty registry.
+func Reset() {
+	tagRegistry.Lock()
+	defer tagRegistry.Unlock()
+
+	tagRegistry.arnTagLocks = make(map[string]map[string]*sync.Mutex)
+	tagRegistry.arnTags = make(map[string]map[string]bool)
+}
@@ -34,7 +34,19 @@ func Provider() p.Provider {
 	return infer.Provider(infer.Options{
 		Resources: []infer.InferredResource{
 			infer.Resource[aws.ResourceTag, aws.ResourceTagArgs, aws.ResourceTagState](),
+			infer.Resource[aws.ResourceTags, aws.ResourceTagsArgs, aws.ResourceTagsState](),
+			infer.Resource[aws.MultiResourceTags, aws.MultiResourceTagsArgs, aws.MultiResourceTagsState](),
+			infer.Resource[aws.QueryResourceTags, aws.QueryResourceTagsArgs, aws.QueryResourceTagsState](),
 		},
+		Functions: []infer.InferredFunction{
+			infer.Function[aws.GetResourceTags, aws.GetResourceTagsArgs, aws.GetResourceTagsResult](),
+			infer.Function[aws.FindResources, aws.FindResourcesArgs, aws.FindResourcesResult](),
+			infer.Function[aws.GetTagKeys, aws.GetTagKeysArgs, aws.GetTagKeysResult](),
+			infer.Function[aws.GetTagValues, aws.GetTagValuesArgs, aws.GetTagValuesResult](),
+			infer.Function[aws.GetComplianceSummary, aws.GetComplianceSummaryArgs, aws.GetComplianceSummaryResult](),
+			infer.Function[aws.GetImportFile, aws.GetImportFileArgs, aws.GetImportFileResult](),
+		},
+		Config: infer.Config[*aws.Config](),
 		ModuleMap: map[tokens.ModuleName]tokens.ModuleName{
 			"provider": "index",
 		},